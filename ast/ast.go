@@ -1,486 +1,880 @@
-package ast
-
-import (
-	"bytes"
-	"strings"
-	"wordbuilder/token"
-)
-
-type Node interface {
-	TokenLiteral() string
-	String() string
-}
-
-type Statement interface {
-	Node
-	statementNode()
-}
-
-type Expression interface {
-	Node
-	expressionNode()
-}
-
-type Program struct {
-	Statements []Statement
-}
-
-func (p *Program) TokenLiteral() string {
-	if len(p.Statements) > 0 {
-		return p.Statements[0].TokenLiteral()
-	}
-	return ""
-}
-
-func (p *Program) String() string {
-	var out bytes.Buffer
-
-	for _, s := range p.Statements {
-		out.WriteString(s.String())
-	}
-
-	return out.String()
-}
-
-type LetStatement struct {
-	Token token.Token // the token.LET token
-	Name  *Identifier
-	Value Expression
-}
-
-func (ls *LetStatement) statementNode()       {}
-func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
-func (ls *LetStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
-	out.WriteString(" = ")
-
-	if ls.Value != nil {
-		out.WriteString(ls.Value.String())
-	}
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-type WordStatement struct {
-	Token      token.Token // the token.WORD token
-	Name       *Identifier
-	Value      Expression
-	Definition string
-	Defined    bool
-}
-
-func (ws *WordStatement) statementNode()       {}
-func (ws *WordStatement) TokenLiteral() string { return ws.Token.Literal }
-func (ws *WordStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(ws.TokenLiteral() + " ")
-	out.WriteString(ws.Name.String())
-	out.WriteString(" = ")
-
-	if ws.Value != nil {
-		out.WriteString(ws.Value.String())
-	}
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-type TranslationStatement struct {
-	Token      token.Token
-	Name       *Identifier
-	Value      Expression
-	Definition string
-	Defined    bool
-}
-
-func (ts *TranslationStatement) statementNode()       {}
-func (ts *TranslationStatement) TokenLiteral() string { return ts.Token.Literal }
-func (ts *TranslationStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(ts.TokenLiteral() + " ")
-	out.WriteString(ts.Name.String())
-	out.WriteString(" = ")
-
-	if ts.Value != nil {
-		out.WriteString(ts.Value.String())
-	}
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-type QuoteStatement struct {
-	By   string
-	Text string
-}
-
-func (qs *QuoteStatement) statementNode()       {}
-func (qs *QuoteStatement) TokenLiteral() string { return qs.Text }
-func (qs *QuoteStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString("\"")
-	out.WriteString(qs.Text)
-	out.WriteString("\" - ")
-	out.WriteString(qs.By)
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-// MeThoughtStatement ...
-type MeThoughtStatement struct {
-	Content string
-	Value   Expression
-}
-
-func (ms *MeThoughtStatement) statementNode()       {}
-func (ms *MeThoughtStatement) TokenLiteral() string { return "me" }
-func (ms *MeThoughtStatement) String() string {
-	var out bytes.Buffer
-	out.WriteString("{")
-	out.WriteString(ms.Content)
-	out.WriteString("}")
-	return out.String()
-}
-
-type ReferenceStatement struct {
-	Token      token.Token // the token.REF token
-	Name       *Identifier
-	Value      Expression
-	Definition string
-	Defined    bool
-}
-
-func (rs *ReferenceStatement) statementNode()       {}
-func (rs *ReferenceStatement) TokenLiteral() string { return rs.Token.Literal }
-func (rs *ReferenceStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(rs.TokenLiteral() + " ")
-	out.WriteString(rs.Name.String())
-	out.WriteString(" = ")
-
-	if rs.Value != nil {
-		out.WriteString(rs.Value.String())
-	}
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-type ConceptStatement struct {
-	Token      token.Token // the token.CPT token
-	Name       *Identifier
-	Value      Expression
-	Definition string
-	Defined    bool
-}
-
-func (cpts *ConceptStatement) statementNode()       {}
-func (cpts *ConceptStatement) TokenLiteral() string { return cpts.Token.Literal }
-func (cpts *ConceptStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(cpts.TokenLiteral() + " ")
-	out.WriteString(cpts.Name.String())
-	out.WriteString(" = ")
-
-	if cpts.Value != nil {
-		out.WriteString(cpts.Value.String())
-	}
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-type Identifier struct {
-	Token token.Token // the token.IDENT token
-	Value string
-}
-
-func (i *Identifier) expressionNode()      {}
-func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
-func (i *Identifier) String() string       { return i.Value }
-
-type ReturnStatement struct {
-	Token       token.Token // the 'return' token
-	ReturnValue Expression
-}
-
-func (rs *ReturnStatement) statementNode()       {}
-func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
-func (rs *ReturnStatement) String() string {
-	var out bytes.Buffer
-
-	out.WriteString(rs.TokenLiteral() + " ")
-
-	if rs.ReturnValue != nil {
-		out.WriteString(rs.ReturnValue.String())
-	}
-
-	out.WriteString(";")
-
-	return out.String()
-}
-
-type ExpressionStatement struct {
-	Token      token.Token // the first token of the expression
-	Expression Expression
-}
-
-func (es *ExpressionStatement) statementNode()       {}
-func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
-func (es *ExpressionStatement) String() string {
-	if es.Expression != nil {
-		return es.Expression.String()
-	}
-	return ""
-}
-
-type IntegerLiteral struct {
-	Token token.Token
-	Value int64
-}
-
-func (il *IntegerLiteral) expressionNode()      {}
-func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
-func (il *IntegerLiteral) String() string       { return il.Token.Literal }
-
-type PrefixExpression struct {
-	Token    token.Token // The prefix token, e.g. !
-	Operator string
-	Right    Expression
-}
-
-func (pe *PrefixExpression) expressionNode()      {}
-func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
-func (pe *PrefixExpression) String() string {
-	var out bytes.Buffer
-
-	out.WriteString("(")
-	out.WriteString(pe.Operator)
-	out.WriteString(pe.Right.String())
-	out.WriteString(")")
-
-	return out.String()
-}
-
-type InfixExpression struct {
-	Token    token.Token // The operator token, e.g. +
-	Left     Expression
-	Operator string
-	Right    Expression
-}
-
-func (oe *InfixExpression) expressionNode() {}
-func (oe *InfixExpression) TokenLiteral() string {
-	return oe.Token.Literal
-}
-
-func (oe *InfixExpression) String() string {
-	var out bytes.Buffer
-	out.WriteString("(")
-	out.WriteString(oe.Left.String())
-	out.WriteString(" " + oe.Operator + " ")
-	out.WriteString(oe.Right.String())
-	out.WriteString(")")
-
-	return out.String()
-}
-
-type Boolean struct {
-	Token token.Token
-	Value bool
-}
-
-func (b *Boolean) expressionNode()      {}
-func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
-func (b *Boolean) String() string       { return b.Token.Literal }
-
-type IfExpression struct {
-	Token       token.Token
-	Condition   Expression
-	Consequence *BlockStatement
-	Alternative *BlockStatement
-}
-
-func (ie *IfExpression) expressionNode() {}
-func (ie *IfExpression) TokenLiteral() string {
-	return ie.Token.Literal
-}
-func (ie *IfExpression) String() string {
-	var out bytes.Buffer
-	out.WriteString("if")
-	out.WriteString(ie.Condition.String())
-	out.WriteString(" ")
-	out.WriteString(ie.Consequence.String())
-
-	if ie.Alternative != nil {
-		out.WriteString("else ")
-		out.WriteString(ie.Alternative.String())
-	}
-
-	return out.String()
-}
-
-type BlockStatement struct {
-	Token      token.Token
-	Statements []Statement
-}
-
-func (bs *BlockStatement) statementNode() {}
-func (bs *BlockStatement) TokenLiteral() string {
-	return bs.Token.Literal
-}
-func (bs *BlockStatement) String() string {
-	var out bytes.Buffer
-	for _, s := range bs.Statements {
-		out.WriteString(s.String())
-	}
-	return out.String()
-}
-
-type FunctionLiteral struct {
-	Token      token.Token // The 'fn' token
-	Parameters []*Identifier
-	Body       *BlockStatement
-}
-
-func (fl *FunctionLiteral) expressionNode() {}
-func (fl *FunctionLiteral) TokenLiteral() string {
-	return fl.Token.Literal
-}
-func (fl *FunctionLiteral) String() string {
-	var out bytes.Buffer
-
-	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
-	}
-
-	out.WriteString(fl.TokenLiteral())
-	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") ")
-	out.WriteString(fl.Body.String())
-
-	return out.String()
-}
-
-type CallExpression struct {
-	Token     token.Token // The '(' token
-	Function  Expression  // Identifier or FunctionLiteral
-	Arguments []Expression
-}
-
-func (ce *CallExpression) expressionNode() {}
-func (ce *CallExpression) TokenLiteral() string {
-	return ce.Token.Literal
-}
-func (ce *CallExpression) String() string {
-	var out bytes.Buffer
-	args := []string{}
-	for _, a := range ce.Arguments {
-		args = append(args, a.String())
-	}
-
-	out.WriteString(ce.Function.String())
-	out.WriteString("(")
-	out.WriteString(strings.Join(args, ", "))
-	out.WriteString(")")
-
-	return out.String()
-}
-
-type StringLiteral struct {
-	Token token.Token
-	Value string
-}
-
-func (sl *StringLiteral) expressionNode() {}
-func (sl *StringLiteral) TokenLiteral() string {
-	return sl.Token.Literal
-}
-func (sl *StringLiteral) String() string {
-	return sl.Token.Literal
-}
-
-type ArrayLiteral struct {
-	token.Token // the '[' token
-	Elements    []Expression
-}
-
-func (al *ArrayLiteral) expressionNode() {}
-func (al *ArrayLiteral) TokenLiteral() string {
-	return al.Token.Literal
-}
-func (al *ArrayLiteral) String() string {
-	var out bytes.Buffer
-
-	elements := []string{}
-	for _, el := range al.Elements {
-		elements = append(elements, el.String())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
-}
-
-type IndexExpression struct {
-	Token token.Token // The [ token
-	Left  Expression
-	Index Expression
-}
-
-func (ie *IndexExpression) expressionNode()      {}
-func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
-func (ie *IndexExpression) String() string {
-	var out bytes.Buffer
-
-	out.WriteString("(")
-	out.WriteString(ie.Left.String())
-	out.WriteString("[")
-	out.WriteString(ie.Index.String())
-	out.WriteString("])")
-
-	return out.String()
-}
-
-type HashLiteral struct {
-	token.Token // the '{' token
-	Pairs       map[Expression]Expression
-}
-
-func (hl *HashLiteral) expressionNode() {}
-func (hl *HashLiteral) TokenLiteral() string {
-	return hl.Token.Literal
-}
-func (hl *HashLiteral) String() string {
-	var out bytes.Buffer
-
-	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
-	}
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
-
-	return out.String()
-}
+package ast
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"wordbuilder/literal"
+	"wordbuilder/token"
+)
+
+type Node interface {
+	TokenLiteral() string
+	String() string
+	Pos() token.Position // position of the first character of the node
+	End() token.Position // position immediately after the node
+}
+
+type Statement interface {
+	Node
+	statementNode()
+}
+
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+type Program struct {
+	Statements []Statement
+	Comments   CommentMap // the comments of the source, by statement
+}
+
+func (p *Program) TokenLiteral() string {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].TokenLiteral()
+	}
+	return ""
+}
+
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
+func (p *Program) String() string {
+	var out bytes.Buffer
+
+	for _, s := range p.Statements {
+		out.WriteString(s.String())
+	}
+
+	return out.String()
+}
+
+type LetStatement struct {
+	Token token.Token // the token.LET token
+	Name  *Identifier
+	Value Expression
+}
+
+func (ls *LetStatement) statementNode()       {}
+func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Pos }
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
+}
+func (ls *LetStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ls.TokenLiteral() + " ")
+	out.WriteString(ls.Name.String())
+	out.WriteString(" = ")
+
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+type WordStatement struct {
+	Token      token.Token // the token.WORD token
+	Name       *Identifier
+	Attributes *AttributeList // the optional `[key: value, ...]` list
+	Value      Expression
+	Definition string
+	Defined    bool
+	Rbrace     token.Position // position of the closing '}', if defined
+	Doc        string         // the comment directly above the entry
+}
+
+func (ws *WordStatement) statementNode()       {}
+func (ws *WordStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WordStatement) Pos() token.Position  { return ws.Token.Pos }
+func (ws *WordStatement) End() token.Position {
+	if ws.Rbrace.IsValid() {
+		return after(ws.Rbrace)
+	}
+	if ws.Attributes != nil {
+		return ws.Attributes.End()
+	}
+	if ws.Name != nil {
+		return ws.Name.End()
+	}
+	return ws.Token.End
+}
+func (ws *WordStatement) String() string {
+	return entryString(ws.TokenLiteral(), literal.Quote(ws.Name.Value), ws.Attributes, ws.Value)
+}
+
+type TranslationStatement struct {
+	Token      token.Token
+	Name       *Identifier
+	Attributes *AttributeList // the optional `[key: value, ...]` list
+	Value      Expression
+	Definition string
+	Defined    bool
+	Rbrace     token.Position // position of the closing '}', if defined
+	Doc        string         // the comment directly above the entry
+}
+
+func (ts *TranslationStatement) statementNode()       {}
+func (ts *TranslationStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TranslationStatement) Pos() token.Position  { return ts.Token.Pos }
+func (ts *TranslationStatement) End() token.Position {
+	if ts.Rbrace.IsValid() {
+		return after(ts.Rbrace)
+	}
+	if ts.Attributes != nil {
+		return ts.Attributes.End()
+	}
+	if ts.Name != nil {
+		return ts.Name.End()
+	}
+	return ts.Token.End
+}
+func (ts *TranslationStatement) String() string {
+	return entryString(ts.TokenLiteral(), ts.Name.Value, ts.Attributes, ts.Value)
+}
+
+type QuoteStatement struct {
+	Token      token.Token // the token.QUOTE token
+	By         string
+	Attributes *AttributeList // the optional `[key: value, ...]` list
+	Text       string
+	Rbrace     token.Position // position of the closing '}'
+}
+
+func (qs *QuoteStatement) statementNode()       {}
+func (qs *QuoteStatement) TokenLiteral() string { return qs.Text }
+func (qs *QuoteStatement) Pos() token.Position  { return qs.Token.Pos }
+func (qs *QuoteStatement) End() token.Position {
+	if qs.Rbrace.IsValid() {
+		return after(qs.Rbrace)
+	}
+	if qs.Attributes != nil {
+		return qs.Attributes.End()
+	}
+	return qs.Token.End
+}
+func (qs *QuoteStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("quote: " + literal.Quote(qs.By))
+	if qs.Attributes != nil {
+		out.WriteString(" " + qs.Attributes.String())
+	}
+	if qs.Rbrace.IsValid() {
+		out.WriteString(" {" + literal.Quote(qs.Text) + "}")
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// MeThoughtStatement ...
+type MeThoughtStatement struct {
+	Token   token.Token // the token.ME token
+	Content string
+	Value   Expression
+	Rbrace  token.Position // position of the closing '}'
+}
+
+func (ms *MeThoughtStatement) statementNode()       {}
+func (ms *MeThoughtStatement) TokenLiteral() string { return "me" }
+func (ms *MeThoughtStatement) Pos() token.Position  { return ms.Token.Pos }
+func (ms *MeThoughtStatement) End() token.Position {
+	if ms.Rbrace.IsValid() {
+		return after(ms.Rbrace)
+	}
+	return ms.Token.End
+}
+func (ms *MeThoughtStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("me: {")
+	if ms.Content != "" {
+		out.WriteString(literal.Quote(ms.Content))
+	}
+	out.WriteString("};")
+	return out.String()
+}
+
+type ReferenceStatement struct {
+	Token      token.Token // the token.REF token
+	Name       *Identifier
+	Attributes *AttributeList // the optional `[key: value, ...]` list
+	Value      Expression
+	Definition string
+	Defined    bool
+	Rbrace     token.Position // position of the closing '}', if defined
+	Doc        string         // the comment directly above the entry
+}
+
+func (rs *ReferenceStatement) statementNode()       {}
+func (rs *ReferenceStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReferenceStatement) Pos() token.Position  { return rs.Token.Pos }
+func (rs *ReferenceStatement) End() token.Position {
+	if rs.Rbrace.IsValid() {
+		return after(rs.Rbrace)
+	}
+	if rs.Attributes != nil {
+		return rs.Attributes.End()
+	}
+	if rs.Name != nil {
+		return rs.Name.End()
+	}
+	return rs.Token.End
+}
+func (rs *ReferenceStatement) String() string {
+	return entryString(rs.TokenLiteral(), literal.Quote(rs.Name.Value), rs.Attributes, rs.Value)
+}
+
+type ConceptStatement struct {
+	Token      token.Token // the token.CPT token
+	Name       *Identifier
+	Attributes *AttributeList // the optional `[key: value, ...]` list
+	Value      Expression
+	Definition string
+	Defined    bool
+	Rbrace     token.Position // position of the closing '}', if defined
+	Doc        string         // the comment directly above the entry
+}
+
+func (cpts *ConceptStatement) statementNode()       {}
+func (cpts *ConceptStatement) TokenLiteral() string { return cpts.Token.Literal }
+func (cpts *ConceptStatement) Pos() token.Position  { return cpts.Token.Pos }
+func (cpts *ConceptStatement) End() token.Position {
+	if cpts.Rbrace.IsValid() {
+		return after(cpts.Rbrace)
+	}
+	if cpts.Attributes != nil {
+		return cpts.Attributes.End()
+	}
+	if cpts.Name != nil {
+		return cpts.Name.End()
+	}
+	return cpts.Token.End
+}
+func (cpts *ConceptStatement) String() string {
+	return entryString(cpts.TokenLiteral(), literal.Quote(cpts.Name.Value), cpts.Attributes, cpts.Value)
+}
+
+// entryString returns the source of a vocabulary entry such as
+// `word: "name" [lang: "es"] {"definition"};`.
+func entryString(keyword, name string, attributes *AttributeList, value Expression) string {
+	var out bytes.Buffer
+
+	out.WriteString(keyword + ": " + name)
+	if attributes != nil {
+		out.WriteString(" " + attributes.String())
+	}
+	if value != nil {
+		out.WriteString(" {" + valueString(value) + "}")
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// valueString is like value.String(), but quotes string literals.
+func valueString(value Expression) string {
+	if str, ok := value.(*StringLiteral); ok {
+		return literal.Quote(str.Value)
+	}
+	return value.String()
+}
+
+// AttributeList holds the metadata of an entry, such as
+// `[tags: ["latin"], lang: "es"]`.
+type AttributeList struct {
+	Token      token.Token // the '[' token
+	Attributes []*Attribute
+	Rbrack     token.Position // position of the closing ']'
+}
+
+// Attribute is one `key: value` pair of an AttributeList.
+type Attribute struct {
+	Key   *Identifier
+	Value Expression
+}
+
+func (al *AttributeList) TokenLiteral() string { return al.Token.Literal }
+func (al *AttributeList) Pos() token.Position  { return al.Token.Pos }
+func (al *AttributeList) End() token.Position {
+	if al.Rbrack.IsValid() {
+		return after(al.Rbrack)
+	}
+	return al.Token.End
+}
+func (al *AttributeList) String() string {
+	attributes := []string{}
+	for _, attr := range al.Attributes {
+		attributes = append(attributes, attr.Key.Value+": "+valueString(attr.Value))
+	}
+	return "[" + strings.Join(attributes, ", ") + "]"
+}
+
+type Identifier struct {
+	Token token.Token // the token.IDENT token
+	Value string
+}
+
+func (i *Identifier) expressionNode()      {}
+func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) Pos() token.Position  { return i.Token.Pos }
+func (i *Identifier) End() token.Position  { return i.Token.End }
+
+type ReturnStatement struct {
+	Token       token.Token // the 'return' token
+	ReturnValue Expression
+}
+
+func (rs *ReturnStatement) statementNode()       {}
+func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Pos() token.Position  { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position  { return nodeEnd(rs.ReturnValue, rs.Token) }
+func (rs *ReturnStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(rs.TokenLiteral() + " ")
+
+	if rs.ReturnValue != nil {
+		out.WriteString(rs.ReturnValue.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ForStatement runs Body once for each element of Iterable. With a single
+// variable, Value is bound to the elements of an array or the keys of a hash;
+// with two, Key is also bound to the index or the key.
+type ForStatement struct {
+	Token    token.Token // the 'for' token
+	Key      *Identifier // nil unless two variables are given
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode()       {}
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+func (fs *ForStatement) Pos() token.Position  { return fs.Token.Pos }
+func (fs *ForStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return nodeEnd(fs.Iterable, fs.Token)
+}
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String() + ", ")
+	}
+	out.WriteString(fs.Value.String() + " in " + fs.Iterable.String() + ") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+type WhileStatement struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode()       {}
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+func (ws *WhileStatement) Pos() token.Position  { return ws.Token.Pos }
+func (ws *WhileStatement) End() token.Position {
+	if ws.Body != nil {
+		return ws.Body.End()
+	}
+	return nodeEnd(ws.Condition, ws.Token)
+}
+func (ws *WhileStatement) String() string {
+	return "while " + ws.Condition.String() + " " + ws.Body.String()
+}
+
+// BranchStatement is a break or a continue.
+type BranchStatement struct {
+	Token token.Token // the 'break' or 'continue' token
+}
+
+func (bs *BranchStatement) statementNode()       {}
+func (bs *BranchStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BranchStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BranchStatement) End() token.Position  { return bs.Token.End }
+func (bs *BranchStatement) String() string       { return bs.Token.Literal + ";" }
+
+// ImportStatement loads the vocabulary of another file, or of every .wb file
+// in a directory.
+type ImportStatement struct {
+	Token token.Token // the 'import' token
+	Path  *StringLiteral
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) Pos() token.Position  { return is.Token.Pos }
+func (is *ImportStatement) End() token.Position {
+	if is.Path != nil {
+		return is.Path.End()
+	}
+	return is.Token.End
+}
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	if is.Path != nil {
+		out.WriteString(literal.Quote(is.Path.Value))
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+type ExpressionStatement struct {
+	Token      token.Token // the first token of the expression
+	Expression Expression
+}
+
+func (es *ExpressionStatement) statementNode()       {}
+func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Pos() token.Position {
+	if es.Expression != nil {
+		return es.Expression.Pos()
+	}
+	return es.Token.Pos
+}
+func (es *ExpressionStatement) End() token.Position { return nodeEnd(es.Expression, es.Token) }
+func (es *ExpressionStatement) String() string {
+	if es.Expression != nil {
+		return es.Expression.String()
+	}
+	return ""
+}
+
+type IntegerLiteral struct {
+	Token token.Token
+	Value int64
+	Big   *big.Int // the value instead of Value if it does not fit in an int64
+}
+
+func (il *IntegerLiteral) expressionNode()      {}
+func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) String() string       { return il.Token.Literal }
+func (il *IntegerLiteral) Pos() token.Position  { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Position  { return il.Token.End }
+
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position  { return fl.Token.End }
+
+type PrefixExpression struct {
+	Token    token.Token // The prefix token, e.g. !
+	Operator string
+	Right    Expression
+}
+
+func (pe *PrefixExpression) expressionNode()      {}
+func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Position  { return nodeEnd(pe.Right, pe.Token) }
+func (pe *PrefixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Operator)
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type InfixExpression struct {
+	Token    token.Token // The operator token, e.g. +
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (oe *InfixExpression) expressionNode() {}
+func (oe *InfixExpression) TokenLiteral() string {
+	return oe.Token.Literal
+}
+
+func (oe *InfixExpression) Pos() token.Position {
+	if oe.Left != nil {
+		return oe.Left.Pos()
+	}
+	return oe.Token.Pos
+}
+
+func (oe *InfixExpression) End() token.Position { return nodeEnd(oe.Right, oe.Token) }
+
+func (oe *InfixExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(oe.Left.String())
+	out.WriteString(" " + oe.Operator + " ")
+	out.WriteString(oe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type Boolean struct {
+	Token token.Token
+	Value bool
+}
+
+func (b *Boolean) expressionNode()      {}
+func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) String() string       { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Pos }
+func (b *Boolean) End() token.Position  { return b.Token.End }
+
+type IfExpression struct {
+	Token       token.Token
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative *BlockStatement
+}
+
+func (ie *IfExpression) expressionNode() {}
+func (ie *IfExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return nodeEnd(ie.Condition, ie.Token)
+}
+func (ie *IfExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("if")
+	out.WriteString(ie.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ie.Consequence.String())
+
+	if ie.Alternative != nil {
+		out.WriteString("else ")
+		out.WriteString(ie.Alternative.String())
+	}
+
+	return out.String()
+}
+
+type BlockStatement struct {
+	Token      token.Token // the '{' token
+	Statements []Statement
+	Rbrace     token.Position // position of the closing '}'
+}
+
+func (bs *BlockStatement) statementNode() {}
+func (bs *BlockStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BlockStatement) Pos() token.Position { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Position {
+	if bs.Rbrace.IsValid() {
+		return after(bs.Rbrace)
+	}
+	return bs.Token.End
+}
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+	}
+	return out.String()
+}
+
+type FunctionLiteral struct {
+	Token      token.Token // The 'fn' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (fl *FunctionLiteral) expressionNode() {}
+func (fl *FunctionLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+func (fl *FunctionLiteral) Pos() token.Position { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(fl.Body.String())
+
+	return out.String()
+}
+
+type CallExpression struct {
+	Token     token.Token // The '(' token
+	Function  Expression  // Identifier or FunctionLiteral
+	Arguments []Expression
+	Rparen    token.Position // position of the closing ')'
+}
+
+func (ce *CallExpression) expressionNode() {}
+func (ce *CallExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+func (ce *CallExpression) End() token.Position {
+	if ce.Rparen.IsValid() {
+		return after(ce.Rparen)
+	}
+	return ce.Token.End
+}
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+type StringLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (sl *StringLiteral) expressionNode() {}
+func (sl *StringLiteral) TokenLiteral() string {
+	return sl.Token.Literal
+}
+func (sl *StringLiteral) Pos() token.Position { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position { return sl.Token.End }
+func (sl *StringLiteral) String() string {
+	return sl.Token.Literal
+}
+
+// InterpolatedString is a string literal with embedded expressions, such as
+// "word ${w.name}". Its parts alternate between text, as StringLiterals, and
+// the embedded expressions, starting and ending with text.
+type InterpolatedString struct {
+	Token token.Token // the STRING_HEAD token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Pos() token.Position  { return is.Token.Pos }
+func (is *InterpolatedString) End() token.Position {
+	if n := len(is.Parts); n > 0 {
+		return is.Parts[n-1].End()
+	}
+	return is.Token.End
+}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+	out.WriteString(`"`)
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(literal.Escape(str.Value))
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString(`"`)
+	return out.String()
+}
+
+type ArrayLiteral struct {
+	token.Token // the '[' token
+	Elements    []Expression
+	Rbrack      token.Position // position of the closing ']'
+}
+
+func (al *ArrayLiteral) expressionNode() {}
+func (al *ArrayLiteral) TokenLiteral() string {
+	return al.Token.Literal
+}
+func (al *ArrayLiteral) Pos() token.Position { return al.Token.Pos }
+func (al *ArrayLiteral) End() token.Position {
+	if al.Rbrack.IsValid() {
+		return after(al.Rbrack)
+	}
+	return al.Token.End
+}
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type IndexExpression struct {
+	Token  token.Token // The [ token
+	Left   Expression
+	Index  Expression
+	Rbrack token.Position // position of the closing ']'
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+func (ie *IndexExpression) End() token.Position {
+	if ie.Rbrack.IsValid() {
+		return after(ie.Rbrack)
+	}
+	return nodeEnd(ie.Index, ie.Token)
+}
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+type FieldExpression struct {
+	Token token.Token // The . token
+	Left  Expression
+	Field *Identifier
+}
+
+func (fe *FieldExpression) expressionNode()      {}
+func (fe *FieldExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe *FieldExpression) Pos() token.Position {
+	if fe.Left != nil {
+		return fe.Left.Pos()
+	}
+	return fe.Token.Pos
+}
+func (fe *FieldExpression) End() token.Position {
+	if fe.Field != nil {
+		return fe.Field.End()
+	}
+	return fe.Token.End
+}
+func (fe *FieldExpression) String() string {
+	return "(" + fe.Left.String() + "." + fe.Field.String() + ")"
+}
+
+type HashLiteral struct {
+	token.Token // the '{' token
+	Pairs       map[Expression]Expression
+	Rbrace      token.Position // position of the closing '}'
+}
+
+func (hl *HashLiteral) expressionNode() {}
+func (hl *HashLiteral) TokenLiteral() string {
+	return hl.Token.Literal
+}
+func (hl *HashLiteral) Pos() token.Position { return hl.Token.Pos }
+func (hl *HashLiteral) End() token.Position {
+	if hl.Rbrace.IsValid() {
+		return after(hl.Rbrace)
+	}
+	return hl.Token.End
+}
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for key, value := range hl.Pairs {
+		pairs = append(pairs, key.String()+":"+value.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// nodeEnd returns the end of node, falling back to the end of tok when the
+// node is missing, e.g. after a parse error.
+func nodeEnd(node Node, tok token.Token) token.Position {
+	if node == nil {
+		return tok.End
+	}
+	return node.End()
+}
+
+// after returns the position following a one-character delimiter at pos.
+func after(pos token.Position) token.Position {
+	pos.Column++
+	return pos
+}
@@ -0,0 +1,130 @@
+package ast
+
+import (
+	"sort"
+	"strings"
+	"wordbuilder/token"
+)
+
+// CommentGroup holds the comments attached to a node.
+type CommentGroup struct {
+	// Leading are the comments on the lines before a statement.
+	Leading []token.Token
+	// Trailing is the comment at the end of the last line of a statement.
+	// For a block or a program it holds the comments after its last
+	// statement instead.
+	Trailing []token.Token
+}
+
+// CommentMap maps statements, blocks and programs to their comments.
+type CommentMap map[Node]*CommentGroup
+
+// NewCommentMap attaches comments, as read by the lexer, to the nearest
+// statement of program. A comment goes to the statement after it, unless it
+// follows a statement on the same line; comments after the last statement
+// of a block or program go to the block or program. Comments inside an
+// expression that holds no block, such as a long call, lead the statement.
+func NewCommentMap(program *Program, comments []token.Token) CommentMap {
+	a := &attacher{comments: comments, cm: CommentMap{}}
+	a.statements(program, program.Statements, token.Position{})
+	return a.cm
+}
+
+type attacher struct {
+	comments []token.Token
+	next     int // index of the next comment to attach
+	cm       CommentMap
+}
+
+// more reports whether the next comment comes before pos. Every comment comes
+// before an invalid pos.
+func (a *attacher) more(pos token.Position) bool {
+	return a.next < len(a.comments) && (!pos.IsValid() || a.comments[a.next].Pos.Before(pos))
+}
+
+func (a *attacher) take() token.Token {
+	a.next++
+	return a.comments[a.next-1]
+}
+
+func (a *attacher) group(node Node) *CommentGroup {
+	if a.cm[node] == nil {
+		a.cm[node] = &CommentGroup{}
+	}
+	return a.cm[node]
+}
+
+// statements attaches the comments before end to stmts, the statements of
+// owner.
+func (a *attacher) statements(owner Node, stmts []Statement, end token.Position) {
+	for i, stmt := range stmts {
+		for a.more(stmt.Pos()) {
+			a.group(stmt).Leading = append(a.group(stmt).Leading, a.take())
+		}
+
+		for _, block := range blocks(stmt) {
+			for a.more(block.Pos()) {
+				a.group(stmt).Leading = append(a.group(stmt).Leading, a.take())
+			}
+			a.statements(block, block.Statements, block.Rbrace)
+		}
+		for a.more(stmt.End()) {
+			a.group(stmt).Leading = append(a.group(stmt).Leading, a.take())
+		}
+
+		limit := end
+		if i+1 < len(stmts) {
+			limit = stmts[i+1].Pos()
+		}
+		if a.more(limit) && a.comments[a.next].Pos.Line == stmt.End().Line {
+			a.group(stmt).Trailing = append(a.group(stmt).Trailing, a.take())
+		}
+	}
+
+	for a.more(end) {
+		a.group(owner).Trailing = append(a.group(owner).Trailing, a.take())
+	}
+}
+
+// blocks returns the outermost blocks inside stmt, in source order.
+func blocks(stmt Statement) []*BlockStatement {
+	if block, ok := stmt.(*BlockStatement); ok {
+		return []*BlockStatement{block}
+	}
+
+	found := []*BlockStatement{}
+	Inspect(stmt, func(n Node) bool {
+		if block, ok := n.(*BlockStatement); ok {
+			found = append(found, block)
+			return false
+		}
+		return true
+	})
+	sort.Slice(found, func(i, j int) bool { return found[i].Pos().Before(found[j].Pos()) })
+	return found
+}
+
+// Doc returns the text of the comments written directly above node, on
+// consecutive lines, without their '#'.
+func (cm CommentMap) Doc(node Node) string {
+	group := cm[node]
+	if group == nil {
+		return ""
+	}
+
+	lines := []string{}
+	line := node.Pos().Line
+	for i := len(group.Leading) - 1; i >= 0; i-- {
+		c := group.Leading[i]
+		if !c.Pos.Before(node.Pos()) {
+			continue // a comment from inside the statement
+		}
+		if c.Pos.Line != line-1 {
+			break
+		}
+		text := strings.TrimRight(strings.TrimPrefix(c.Literal, "#"), " \t")
+		lines = append([]string{strings.TrimPrefix(text, " ")}, lines...)
+		line = c.Pos.Line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,145 @@
+package ast
+
+import "sort"
+
+// Inspect traverses the tree rooted at node in source order, calling f for
+// every node. If f returns false the children of that node are skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+
+	case *LetStatement:
+		inspectIdentifier(n.Name, f)
+		Inspect(n.Value, f)
+	case *WordStatement:
+		inspectIdentifier(n.Name, f)
+		inspectAttributes(n.Attributes, f)
+		Inspect(n.Value, f)
+	case *ReferenceStatement:
+		inspectIdentifier(n.Name, f)
+		inspectAttributes(n.Attributes, f)
+		Inspect(n.Value, f)
+	case *ConceptStatement:
+		inspectIdentifier(n.Name, f)
+		inspectAttributes(n.Attributes, f)
+		Inspect(n.Value, f)
+	case *TranslationStatement:
+		inspectIdentifier(n.Name, f)
+		inspectAttributes(n.Attributes, f)
+		Inspect(n.Value, f)
+	case *QuoteStatement:
+		inspectAttributes(n.Attributes, f)
+	case *MeThoughtStatement:
+		Inspect(n.Value, f)
+	case *ReturnStatement:
+		Inspect(n.ReturnValue, f)
+	case *ImportStatement:
+		if n.Path != nil {
+			Inspect(n.Path, f)
+		}
+	case *ExpressionStatement:
+		Inspect(n.Expression, f)
+	case *ForStatement:
+		inspectIdentifier(n.Key, f)
+		inspectIdentifier(n.Value, f)
+		Inspect(n.Iterable, f)
+		if n.Body != nil {
+			Inspect(n.Body, f)
+		}
+	case *WhileStatement:
+		Inspect(n.Condition, f)
+		if n.Body != nil {
+			Inspect(n.Body, f)
+		}
+
+	case *BlockStatement:
+		for _, stmt := range n.Statements {
+			Inspect(stmt, f)
+		}
+
+	case *InterpolatedString:
+		for _, part := range n.Parts {
+			Inspect(part, f)
+		}
+
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+
+	case *IfExpression:
+		Inspect(n.Condition, f)
+		if n.Consequence != nil {
+			Inspect(n.Consequence, f)
+		}
+		if n.Alternative != nil {
+			Inspect(n.Alternative, f)
+		}
+
+	case *FunctionLiteral:
+		for _, param := range n.Parameters {
+			inspectIdentifier(param, f)
+		}
+		if n.Body != nil {
+			Inspect(n.Body, f)
+		}
+
+	case *CallExpression:
+		Inspect(n.Function, f)
+		for _, arg := range n.Arguments {
+			Inspect(arg, f)
+		}
+	case *ArrayLiteral:
+		for _, elem := range n.Elements {
+			Inspect(elem, f)
+		}
+	case *IndexExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Index, f)
+	case *FieldExpression:
+		Inspect(n.Left, f)
+		inspectIdentifier(n.Field, f)
+
+	case *AttributeList:
+		for _, attr := range n.Attributes {
+			inspectIdentifier(attr.Key, f)
+			Inspect(attr.Value, f)
+		}
+
+	case *HashLiteral:
+		for _, key := range n.Keys() {
+			Inspect(key, f)
+			Inspect(n.Pairs[key], f)
+		}
+	}
+}
+
+func inspectIdentifier(ident *Identifier, f func(Node) bool) {
+	if ident != nil {
+		Inspect(ident, f)
+	}
+}
+
+func inspectAttributes(attributes *AttributeList, f func(Node) bool) {
+	if attributes != nil {
+		Inspect(attributes, f)
+	}
+}
+
+// Keys returns the keys of the hash in the order they appear in the source.
+func (hl *HashLiteral) Keys() []Expression {
+	keys := make([]Expression, 0, len(hl.Pairs))
+	for key := range hl.Pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Pos().Before(keys[j].Pos()) })
+	return keys
+}
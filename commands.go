@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"wordbuilder/object"
+	"wordbuilder/repl"
+)
+
+func runCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("run"))
+	if code := c.parseFlags(fs, args, 1, 1); code >= 0 {
+		return code
+	}
+
+	evaluated, ok := c.load(fs.Arg(0), object.NewEnvironment(), c.stdout)
+	if !ok {
+		return exitError
+	}
+
+	if evaluated != nil {
+		io.WriteString(c.stdout, evaluated.Inspect())
+		io.WriteString(c.stdout, "\n")
+	}
+	return exitOK
+}
+
+func replCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("repl"))
+	if code := c.parseFlags(fs, args, 0, 1); code >= 0 {
+		return code
+	}
+
+	env := object.NewEnvironment()
+	if fs.NArg() == 1 {
+		if _, ok := c.load(fs.Arg(0), env, c.stdout); !ok {
+			return exitError
+		}
+	}
+
+	repl.Start(c.stdin, c.stdout, env)
+	return exitOK
+}
+
+func checkCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("check"))
+	if code := c.parseFlags(fs, args, 1, -1); code >= 0 {
+		return code
+	}
+
+	code := exitOK
+	for _, path := range fs.Args() {
+		if _, ok := c.parseFile(path); !ok {
+			code = exitError
+		}
+	}
+	return code
+}
+
+func statsCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("stats"))
+	if code := c.parseFlags(fs, args, 1, 1); code >= 0 {
+		return code
+	}
+
+	env, ok := c.loadQuietly(fs.Arg(0))
+	if !ok {
+		return exitError
+	}
+
+	kb := env.Knowledge()
+	counts := map[object.Type]int{}
+	defined := map[object.Type]int{}
+	for _, entry := range kb.Entries(object.DefinitionOrder) {
+		counts[entry.Type()]++
+		if entry.Text() != "" {
+			defined[entry.Type()]++
+		}
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	rows := []struct {
+		label string
+		kind  object.Type
+	}{
+		{"words", object.WordObj},
+		{"references", object.ReferenceObj},
+		{"concepts", object.ConceptObj},
+		{"translations", object.TranslationObj},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%d\t(%d defined)\n", row.label, counts[row.kind], defined[row.kind])
+	}
+	fmt.Fprintf(w, "thoughts\t%d\n", len(kb.Thoughts()))
+	fmt.Fprintf(w, "quotes\t%d\n", len(kb.Quotes()))
+	fmt.Fprintf(w, "files\t%d\n", len(kb.Files()))
+	w.Flush()
+
+	return exitOK
+}
+
+func searchCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("search"))
+	defs := fs.Bool("defs", false, "also match the term against definitions")
+	order := fs.String("order", "definition", "order of the results: definition, alpha or kind")
+	if code := c.parseFlags(fs, args, 2, 2); code >= 0 {
+		return code
+	}
+
+	entryOrder, ok := object.LookupOrder(*order)
+	if !ok {
+		fmt.Fprintf(c.stderr, "wordbuilder search: unknown order %q\n", *order)
+		return exitUsage
+	}
+
+	env, ok := c.loadQuietly(fs.Arg(1))
+	if !ok {
+		return exitError
+	}
+
+	term := strings.ToLower(fs.Arg(0))
+	found := false
+	for _, entry := range env.Knowledge().Entries(entryOrder) {
+		match := strings.Contains(strings.ToLower(entry.Name()), term)
+		if *defs && !match {
+			match = strings.Contains(strings.ToLower(entry.Text()), term)
+		}
+		if match {
+			fmt.Fprintf(c.stdout, "%s: %s\n", kindName(entry), entry.Name())
+			found = true
+		}
+	}
+
+	if !found {
+		return exitError
+	}
+	return exitOK
+}
+
+func helpCommand(c *cli, args []string) int {
+	if len(args) == 0 {
+		c.usage(c.stdout)
+		return exitOK
+	}
+
+	cmd := lookupCommand(args[0])
+	if cmd == nil || cmd.name == "help" {
+		fmt.Fprintf(c.stderr, "wordbuilder help: unknown command %q\n", args[0])
+		return exitUsage
+	}
+
+	// Running a command with -h prints its usage and flags.
+	stderr := c.stderr
+	c.stderr = c.stdout
+	defer func() { c.stderr = stderr }()
+
+	return cmd.run(c, []string{"-h"})
+}
+
+// kindName returns the keyword an entry is defined with, such as "word".
+func kindName(entry object.Entry) string {
+	return strings.ToLower(string(entry.Type()))
+}
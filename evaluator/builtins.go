@@ -1,338 +1,646 @@
-package evaluator
-
-import (
-	"fmt"
-	"strings"
-	"wordbuilder/object"
-)
-
-var builtins = map[string]*object.Builtin{
-
-	"grep": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			if len(args) != 1 || args[0].Type() != object.StringObj {
-				return newError("argument to `grep` must be STRING, got %s", args[0].Type())
-			}
-
-			newElements := make([]object.Object, 0)
-
-			s, _ := args[0].(*object.String)
-			for k := range env.Store() {
-				if strings.Contains(k, s.Inspect()) {
-					newElements = append(newElements, &object.String{Value: k})
-				}
-			}
-
-			return &object.Array{Elements: newElements}
-		},
-	},
-
-	"len": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			switch arg := args[0].(type) {
-			case *object.Array:
-				return &object.Integer{Value: int64(len(arg.Elements))}
-			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
-			case *object.Hash:
-				return &object.Integer{Value: int64(len(arg.Pairs))}
-			default:
-				return newError("argument to `len` not supported, got %s",
-					args[0].Type())
-			}
-		},
-	},
-
-	"max": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) < 1 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
-			}
-
-			max, ok := args[0].(*object.Integer)
-			if !ok {
-				return newError("arguments to `max` not supported, got %s", args[0].Type())
-			}
-
-			for _, arg := range args {
-				n, ok := arg.(*object.Integer)
-				if !ok {
-					return newError("argument to `max` not supported, got %s", arg.Type())
-				}
-				if n.Value > max.Value {
-					max = n
-				}
-			}
-			return &object.Integer{Value: max.Value}
-		},
-	},
-
-	"exists": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-
-			if len(args) != 1 || args[0].Type() != object.StringObj {
-				return newError("argument to `exists` must be STRING, got %s", args[0].Type())
-			}
-
-			str := args[0].(*object.String)
-			_, ok := env.Get(str.Value)
-			return nativeBoolToBooleanIObject(ok)
-		},
-	},
-
-	"defined": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-
-			if len(args) != 1 || args[0].Type() != object.StringObj {
-				return newError("argument to `defined` must be STRING, got %s", args[0].Type())
-			}
-
-			str := args[0].(*object.String)
-			obj, ok := env.Get(str.Value)
-
-			if ok {
-				word, ok := obj.(*object.Word)
-				if ok {
-					if word != nil {
-						return nativeBoolToBooleanIObject(word.Definition != "")
-					}
-				}
-			}
-
-			return FALSE
-		},
-	},
-
-	"first": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 1 || args[0].Type() != object.ArrayObj {
-				return newError("argument to `first` must be ARRAY, got %s",
-					args[0].Type())
-			}
-
-			arr := args[0].(*object.Array)
-			if len(arr.Elements) > 0 {
-				return arr.Elements[0]
-			}
-
-			return NULL
-		},
-	},
-
-	"last": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 1 || args[0].Type() != object.ArrayObj {
-				return newError("argument to `last` must be ARRAY, got %s",
-					args[0].Type())
-			}
-
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			if length > 0 {
-				return arr.Elements[length-1]
-			}
-			return NULL
-		},
-	},
-
-	"rest": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 1 || args[0].Type() != object.ArrayObj {
-				return newError("argument to `rest` must be ARRAY, got %s",
-					args[0].Type())
-			}
-
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-			if length > 0 {
-				newElements := make([]object.Object, length-1, length-1)
-				copy(newElements, arr.Elements[1:length])
-				return &object.Array{Elements: newElements}
-			}
-
-			return NULL
-		},
-	},
-
-	"push": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			if len(args) != 2 && args[0].Type() != object.ArrayObj {
-				return newError("argument to `push` must be ARRAY, got %s",
-					args[0].Type())
-			}
-
-			arr := args[0].(*object.Array)
-			length := len(arr.Elements)
-
-			newElements := make([]object.Object, length+1, length+1)
-			copy(newElements, arr.Elements)
-			newElements[length] = args[1]
-
-			return &object.Array{Elements: newElements}
-		},
-	},
-
-	"puts": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			for _, arg := range args {
-				fmt.Println(arg.Inspect())
-			}
-			return NULL
-		},
-	},
-
-	"printwords": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			for k, v := range env.Store() {
-				if v == nil {
-					fmt.Printf("%q: \"\"\n", k)
-				} else {
-					fmt.Printf("%q: %q\n", k, v.Inspect())
-				}
-			}
-			return NULL
-		},
-	},
-
-	"wc": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			return &object.Integer{Value: int64(len(env.Store()))}
-		},
-	},
-
-	"wordcount": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			c := 0
-			for _, v := range env.Store() {
-				if _, ok := v.(*object.Word); ok {
-					c++
-				}
-			}
-			return &object.Integer{Value: int64(c)}
-		},
-	},
-
-	"refcount": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			c := 0
-			for _, v := range env.Store() {
-				if _, ok := v.(*object.Reference); ok {
-					c++
-				}
-			}
-			return &object.Integer{Value: int64(c)}
-		},
-	},
-
-	"trcount": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			c := 0
-			for _, v := range env.Store() {
-				if _, ok := v.(*object.Translation); ok {
-					c++
-				}
-			}
-			return &object.Integer{Value: int64(c)}
-		},
-	},
-
-	"cptcount": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			c := 0
-			for _, v := range env.Store() {
-				if _, ok := v.(*object.Concept); ok {
-					c++
-				}
-			}
-			return &object.Integer{Value: int64(c)}
-		},
-	},
-
-	"mecount": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			c := 0
-			for _, v := range env.Store() {
-				if _, ok := v.(*object.MeThought); ok {
-					c++
-				}
-			}
-			return &object.Integer{Value: int64(c)}
-		},
-	},
-
-	"thoughts": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			elements := []object.Object{}
-			for _, th := range env.Thoughts() {
-				elements = append(elements, &object.String{Value: th})
-			}
-			return &object.Array{Elements: elements}
-		},
-	},
-
-	"quotes": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-			elements := []object.Object{}
-			for _, q := range env.Quotes() {
-				elements = append(elements, &object.String{Value: q.Inspect()})
-			}
-			return &object.Array{Elements: elements}
-		},
-	},
-
-	"counts": &object.Builtin{
-		Fn: func(env *object.Environment, args ...object.Object) object.Object {
-
-			pairs := make(map[object.HashKey]object.HashPair)
-
-			var meCount, wordsCount, trCount, refCount, quoteCount int
-
-			for _, v := range env.Store() {
-
-				switch v.(type) {
-				case *object.MeThought:
-					meCount++
-				case *object.Word:
-					wordsCount++
-				case *object.Translation:
-					trCount++
-				case *object.Reference:
-					refCount++
-				case *object.Quote:
-					quoteCount++
-				}
-
-			}
-
-			wordsCountObj := &object.String{Value: "words"}
-			hashed := wordsCountObj.HashKey()
-			pairs[hashed] = object.HashPair{Key: wordsCountObj, Value: &object.Integer{Value: int64(wordsCount)}}
-
-			refsCountObj := &object.String{Value: "refs"}
-			hashed = refsCountObj.HashKey()
-			pairs[hashed] = object.HashPair{Key: refsCountObj, Value: &object.Integer{Value: int64(refCount)}}
-
-			transCountObj := &object.String{Value: "trs"}
-			hashed = transCountObj.HashKey()
-			pairs[hashed] = object.HashPair{Key: refsCountObj, Value: &object.Integer{Value: int64(trCount)}}
-
-			thoughtsCountObj := &object.String{Value: "thoughts"}
-			hashed = thoughtsCountObj.HashKey()
-			pairs[hashed] = object.HashPair{Key: refsCountObj, Value: &object.Integer{Value: int64(meCount)}}
-
-			quotesCountObj := &object.String{Value: "quotes"}
-			hashed = quotesCountObj.HashKey()
-			pairs[hashed] = object.HashPair{Key: refsCountObj, Value: &object.Integer{Value: int64(quoteCount)}}
-
-			return &object.Hash{Pairs: pairs}
-		},
-	},
-}
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"wordbuilder/object"
+	"wordbuilder/serializer"
+)
+
+// Stdout receives the output of builtins such as puts and printwords.
+var Stdout io.Writer = os.Stdout
+
+var builtins = map[string]*object.Builtin{
+
+	"grep": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+			}
+
+			if args[0].Type() != object.StringObj {
+				return newError("argument to `grep` must be STRING, got %s", args[0].Type())
+			}
+
+			order, err := entryOrder("grep", args[1:])
+			if err != nil {
+				return err
+			}
+
+			newElements := make([]object.Object, 0)
+
+			s, _ := args[0].(*object.String)
+			for _, entry := range env.Knowledge().Entries(order) {
+				if strings.Contains(entry.Name(), s.Inspect()) {
+					newElements = append(newElements, &object.String{Value: entry.Name()})
+				}
+			}
+
+			return &object.Array{Elements: newElements}
+		},
+	},
+
+	"len": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.String:
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
+			default:
+				return newError("argument to `len` not supported, got %s",
+					args[0].Type())
+			}
+		},
+	},
+
+	"max": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			max, ok := args[0].(*object.Integer)
+			if !ok {
+				return newError("arguments to `max` not supported, got %s", args[0].Type())
+			}
+
+			for _, arg := range args {
+				n, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("argument to `max` not supported, got %s", arg.Type())
+				}
+				if n.Value > max.Value {
+					max = n
+				}
+			}
+			return &object.Integer{Value: max.Value}
+		},
+	},
+
+	"exists": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if args[0].Type() != object.StringObj {
+				return newError("argument to `exists` must be STRING, got %s", args[0].Type())
+			}
+
+			str := args[0].(*object.String)
+			_, ok := env.Knowledge().Lookup(str.Value)
+			return nativeBoolToBooleanIObject(ok)
+		},
+	},
+
+	"defined": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if args[0].Type() != object.StringObj {
+				return newError("argument to `defined` must be STRING, got %s", args[0].Type())
+			}
+
+			str := args[0].(*object.String)
+			obj, ok := env.Knowledge().Lookup(str.Value)
+
+			if ok {
+				word, ok := obj.(*object.Word)
+				if ok {
+					if word != nil {
+						return nativeBoolToBooleanIObject(word.Definition != "")
+					}
+				}
+			}
+
+			return FALSE
+		},
+	},
+
+	"int": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInteger:
+				return arg
+			case *object.Float:
+				if math.IsInf(arg.Value, 0) || math.IsNaN(arg.Value) {
+					return newError("cannot convert %s to INTEGER", arg.Inspect())
+				}
+				n, _ := big.NewFloat(arg.Value).Int(nil)
+				return object.NewInteger(n)
+			case *object.String:
+				n, ok := new(big.Int).SetString(strings.TrimSpace(arg.Value), 10)
+				if !ok {
+					return newError("cannot convert %q to INTEGER", arg.Value)
+				}
+				return object.NewInteger(n)
+			default:
+				return newError("argument to `int` not supported, got %s", arg.Type())
+			}
+		},
+	},
+
+	"float": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInteger, *object.Float:
+				f := object.ToFloat(arg)
+				if math.IsInf(f, 0) {
+					return newError("float overflow: float(%s)", arg.Inspect())
+				}
+				return &object.Float{Value: f}
+			case *object.String:
+				// ParseFloat also accepts "inf" and "NaN"; FLOATs are finite.
+				f, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+					return newError("cannot convert %q to FLOAT", arg.Value)
+				}
+				return &object.Float{Value: f}
+			default:
+				return newError("argument to `float` not supported, got %s", arg.Type())
+			}
+		},
+	},
+
+	"str": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+
+	"doc": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			entry, result := entryArgument(env, "doc", args[0])
+			if entry == nil {
+				return result
+			}
+			return &object.String{Value: entry.Doc()}
+		},
+	},
+
+	"attrs": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			entry, result := entryArgument(env, "attrs", args[0])
+			if entry == nil {
+				return result
+			}
+			return entry.Attributes().Hash()
+		},
+	},
+
+	"first": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if args[0].Type() != object.ArrayObj {
+				return newError("argument to `first` must be ARRAY, got %s",
+					args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			if len(arr.Elements) > 0 {
+				return arr.Elements[0]
+			}
+
+			return NULL
+		},
+	},
+
+	"last": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if args[0].Type() != object.ArrayObj {
+				return newError("argument to `last` must be ARRAY, got %s",
+					args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length > 0 {
+				return arr.Elements[length-1]
+			}
+			return NULL
+		},
+	},
+
+	"rest": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if args[0].Type() != object.ArrayObj {
+				return newError("argument to `rest` must be ARRAY, got %s",
+					args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length > 0 {
+				newElements := make([]object.Object, length-1, length-1)
+				copy(newElements, arr.Elements[1:length])
+				return &object.Array{Elements: newElements}
+			}
+
+			return NULL
+		},
+	},
+
+	"push": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+
+			if args[0].Type() != object.ArrayObj {
+				return newError("argument to `push` must be ARRAY, got %s",
+					args[0].Type())
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+
+			newElements := make([]object.Object, length+1, length+1)
+			copy(newElements, arr.Elements)
+			newElements[length] = args[1]
+
+			return &object.Array{Elements: newElements}
+		},
+	},
+
+	"puts": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprintln(Stdout, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+
+	"printwords": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			order, err := entryOrder("printwords", args)
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range env.Knowledge().Entries(order) {
+				fmt.Fprintf(Stdout, "%q: %q\n", entry.Name(), entry.Inspect())
+			}
+			return NULL
+		},
+	},
+
+	"wc": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.Integer{Value: int64(env.Knowledge().Len())}
+		},
+	},
+
+	"wordcount": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			c := 0
+			for _, v := range env.Knowledge().Entries(object.DefinitionOrder) {
+				if _, ok := v.(*object.Word); ok {
+					c++
+				}
+			}
+			return &object.Integer{Value: int64(c)}
+		},
+	},
+
+	"refcount": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			c := 0
+			for _, v := range env.Knowledge().Entries(object.DefinitionOrder) {
+				if _, ok := v.(*object.Reference); ok {
+					c++
+				}
+			}
+			return &object.Integer{Value: int64(c)}
+		},
+	},
+
+	"trcount": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			c := 0
+			for _, v := range env.Knowledge().Entries(object.DefinitionOrder) {
+				if _, ok := v.(*object.Translation); ok {
+					c++
+				}
+			}
+			return &object.Integer{Value: int64(c)}
+		},
+	},
+
+	"cptcount": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			c := 0
+			for _, v := range env.Knowledge().Entries(object.DefinitionOrder) {
+				if _, ok := v.(*object.Concept); ok {
+					c++
+				}
+			}
+			return &object.Integer{Value: int64(c)}
+		},
+	},
+
+	"words":        entriesBuiltin("words", object.WordObj),
+	"refs":         entriesBuiltin("refs", object.ReferenceObj),
+	"concepts":     entriesBuiltin("concepts", object.ConceptObj),
+	"translations": entriesBuiltin("translations", object.TranslationObj),
+
+	"mecount": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.Integer{Value: int64(len(env.Thoughts()))}
+		},
+	},
+
+	"thoughts": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			elements := []object.Object{}
+			for _, th := range env.Thoughts() {
+				elements = append(elements, &object.String{Value: th})
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+
+	"quotes": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			elements := []object.Object{}
+			for _, q := range env.Quotes() {
+				q := q
+				elements = append(elements, &q)
+			}
+			return &object.Array{Elements: elements}
+		},
+	},
+
+	"save": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if args[0].Type() != object.StringObj {
+				return newError("argument to `save` must be STRING, got %s", args[0].Type())
+			}
+
+			path := args[0].(*object.String).Value
+			source, err := serializer.String(env.Knowledge())
+			if err != nil {
+				return newError("cannot save %q: %s", path, err)
+			}
+
+			if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+				return newError("cannot save %q: %s", path, errorText(err))
+			}
+
+			return NULL
+		},
+	},
+
+	"counts": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+
+			pairs := make(map[object.HashKey]object.HashPair)
+
+			var wordsCount, trCount, refCount, cptCount int
+
+			for _, v := range env.Knowledge().Entries(object.DefinitionOrder) {
+
+				switch v.(type) {
+				case *object.Word:
+					wordsCount++
+				case *object.Translation:
+					trCount++
+				case *object.Reference:
+					refCount++
+				case *object.Concept:
+					cptCount++
+				}
+
+			}
+
+			counts := []struct {
+				key   string
+				count int
+			}{
+				{"words", wordsCount},
+				{"refs", refCount},
+				{"cpts", cptCount},
+				{"trs", trCount},
+				{"thoughts", len(env.Thoughts())},
+				{"quotes", len(env.Quotes())},
+			}
+
+			for _, c := range counts {
+				key := &object.String{Value: c.key}
+				pairs[key.HashKey()] = object.HashPair{Key: key, Value: &object.Integer{Value: int64(c.count)}}
+			}
+
+			return &object.Hash{Pairs: pairs}
+		},
+	},
+}
+
+// entryOrder reads the optional ordering argument of the listing builtins.
+func entryOrder(fn string, args []object.Object) (object.Order, *object.Error) {
+	if len(args) == 0 {
+		return object.DefinitionOrder, nil
+	}
+
+	if len(args) != 1 || args[0].Type() != object.StringObj {
+		return 0, newError("order argument to `%s` must be STRING", fn)
+	}
+
+	name := args[0].(*object.String).Value
+	order, ok := object.LookupOrder(name)
+	if !ok {
+		return 0, newError("unknown order for `%s`: %q, want \"definition\", \"alpha\" or \"kind\"", fn, name)
+	}
+
+	return order, nil
+}
+
+// entriesBuiltin returns the builtin fn, which lists the entries of one kind.
+// It takes an optional hash of filters:
+//
+//	words({"defined": true, "tag": "latin", "order": "alpha"})
+//
+// "defined" keeps the entries with (or without) a definition, "tag" those
+// whose tags attribute holds the tag, and "order" is as for printwords.
+func entriesBuiltin(fn string, kind object.Type) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+			}
+
+			filter, err := newEntryFilter(fn, args)
+			if err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, entry := range env.Knowledge().Entries(filter.order) {
+				if entry.Type() == kind && filter.keep(entry) {
+					elements = append(elements, entry)
+				}
+			}
+			return &object.Array{Elements: elements}
+		},
+	}
+}
+
+type entryFilter struct {
+	order   object.Order
+	defined *bool
+	tag     *string
+}
+
+func newEntryFilter(fn string, args []object.Object) (entryFilter, *object.Error) {
+	filter := entryFilter{order: object.DefinitionOrder}
+	if len(args) == 0 {
+		return filter, nil
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return filter, newError("argument to `%s` must be HASH, got %s", fn, args[0].Type())
+	}
+
+	for _, pair := range hash.Pairs {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return filter, newError("filter of `%s` must have STRING keys, got %s", fn, pair.Key.Type())
+		}
+
+		switch key.Value {
+		case "defined":
+			defined, ok := pair.Value.(*object.Boolean)
+			if !ok {
+				return filter, newError("filter \"defined\" of `%s` must be BOOLEAN, got %s", fn, pair.Value.Type())
+			}
+			filter.defined = &defined.Value
+		case "tag":
+			tag, ok := pair.Value.(*object.String)
+			if !ok {
+				return filter, newError("filter \"tag\" of `%s` must be STRING, got %s", fn, pair.Value.Type())
+			}
+			filter.tag = &tag.Value
+		case "order":
+			order, err := entryOrder(fn, []object.Object{pair.Value})
+			if err != nil {
+				return filter, err
+			}
+			filter.order = order
+		default:
+			return filter, newError("unknown filter for `%s`: %q, want \"defined\", \"tag\" or \"order\"", fn, key.Value)
+		}
+	}
+
+	return filter, nil
+}
+
+func (f entryFilter) keep(entry object.Entry) bool {
+	if f.defined != nil && (entry.Text() != "") != *f.defined {
+		return false
+	}
+	if f.tag != nil && !hasTag(entry, *f.tag) {
+		return false
+	}
+	return true
+}
+
+// hasTag reports whether the tags attribute of entry is tag, or an array
+// that holds it.
+func hasTag(entry object.Entry, tag string) bool {
+	tags, _ := entry.Attributes().Get("tags")
+	switch tags := tags.(type) {
+	case *object.String:
+		return tags.Value == tag
+	case *object.Array:
+		for _, elem := range tags.Elements {
+			if s, ok := elem.(*object.String); ok && s.Value == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuiltinNames returns the names of the builtin functions, sorted.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// entryArgument returns the entry passed to the builtin fn, either as an
+// entry object or by name. If there is none it returns NULL for an unknown
+// name, or an error.
+func entryArgument(env *object.Environment, fn string, arg object.Object) (object.Entry, object.Object) {
+	switch arg := arg.(type) {
+	case object.Entry:
+		return arg, nil
+	case *object.String:
+		if entry, ok := env.Knowledge().Lookup(arg.Value); ok {
+			return entry, nil
+		}
+		return nil, NULL
+	default:
+		return nil, newError("argument to `%s` must be STRING or an entry, got %s", fn, arg.Type())
+	}
+}
@@ -0,0 +1,254 @@
+package evaluator
+
+import (
+	"sort"
+	"wordbuilder/object"
+)
+
+// The builtins that take a function call back into the evaluator, which
+// itself looks up builtins, so they are added to the table in init to break
+// the initialization cycle.
+func init() {
+	for name, fn := range map[string]object.BuiltinFunction{
+		"map":      mapBuiltin,
+		"filter":   filterBuiltin,
+		"reduce":   reduceBuiltin,
+		"sort_by":  sortByBuiltin,
+		"group_by": groupByBuiltin,
+		"any":      anyBuiltin,
+		"all":      allBuiltin,
+		"zip":      zipBuiltin,
+		"uniq":     uniqBuiltin,
+	} {
+		builtins[name] = &object.Builtin{Fn: fn}
+	}
+}
+
+// map(array, fn) returns the results of fn on each element.
+func mapBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunction("map", args)
+	if err != nil {
+		return err
+	}
+
+	elements := make([]object.Object, len(arr.Elements))
+	for i, elem := range arr.Elements {
+		result := applyFunction(env, fn, []object.Object{elem})
+		if isError(result) {
+			return result
+		}
+		elements[i] = result
+	}
+	return &object.Array{Elements: elements}
+}
+
+// filter(array, fn) returns the elements for which fn is truthy.
+func filterBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunction("filter", args)
+	if err != nil {
+		return err
+	}
+
+	elements := []object.Object{}
+	for _, elem := range arr.Elements {
+		result := applyFunction(env, fn, []object.Object{elem})
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			elements = append(elements, elem)
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// reduce(array, initial, fn) folds the array into fn(fn(initial, a), b)...
+func reduceBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=3", len(args))
+	}
+
+	arr, fn, err := arrayAndFunction("reduce", []object.Object{args[0], args[2]})
+	if err != nil {
+		return err
+	}
+
+	acc := args[1]
+	for _, elem := range arr.Elements {
+		acc = applyFunction(env, fn, []object.Object{acc, elem})
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+// sort_by(array, fn) returns the elements sorted by the number or STRING that
+// fn returns for each of them. Elements with equal keys keep their order.
+func sortByBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunction("sort_by", args)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]object.Object, len(arr.Elements))
+	for i, elem := range arr.Elements {
+		key := applyFunction(env, fn, []object.Object{elem})
+		if isError(key) {
+			return key
+		}
+		if !object.IsNumber(key) && key.Type() != object.StringObj {
+			return newError("keys of `sort_by` must be numbers or STRING, got %s", key.Type())
+		}
+		if i > 0 && object.IsNumber(key) != object.IsNumber(keys[0]) {
+			return newError("keys of `sort_by` must all have the same type, got %s and %s", keys[0].Type(), key.Type())
+		}
+		keys[i] = key
+	}
+
+	order := make([]int, len(arr.Elements))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return evalInfixExpression("<", keys[order[i]], keys[order[j]]) == TRUE
+	})
+
+	elements := make([]object.Object, len(order))
+	for i, idx := range order {
+		elements[i] = arr.Elements[idx]
+	}
+	return &object.Array{Elements: elements}
+}
+
+// group_by(array, fn) returns a hash from each key fn returns to the array
+// of elements with that key.
+func groupByBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	arr, fn, err := arrayAndFunction("group_by", args)
+	if err != nil {
+		return err
+	}
+
+	pairs := make(map[object.HashKey]object.HashPair)
+	for _, elem := range arr.Elements {
+		key := applyFunction(env, fn, []object.Object{elem})
+		if isError(key) {
+			return key
+		}
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		hashed := hashKey.HashKey()
+		group, ok := pairs[hashed]
+		if !ok {
+			group = object.HashPair{Key: key, Value: &object.Array{}}
+		}
+		group.Value.(*object.Array).Elements = append(group.Value.(*object.Array).Elements, elem)
+		pairs[hashed] = group
+	}
+	return &object.Hash{Pairs: pairs}
+}
+
+// any(array, fn) reports whether fn is truthy for some element.
+func anyBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	return findBuiltin(env, "any", args, true)
+}
+
+// all(array, fn) reports whether fn is truthy for every element.
+func allBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	return findBuiltin(env, "all", args, false)
+}
+
+// findBuiltin returns want as soon as fn returns it for an element, and !want
+// if it never does.
+func findBuiltin(env *object.Environment, name string, args []object.Object, want bool) object.Object {
+	arr, fn, err := arrayAndFunction(name, args)
+	if err != nil {
+		return err
+	}
+
+	for _, elem := range arr.Elements {
+		result := applyFunction(env, fn, []object.Object{elem})
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) == want {
+			return nativeBoolToBooleanIObject(want)
+		}
+	}
+	return nativeBoolToBooleanIObject(!want)
+}
+
+// zip(a, b) pairs up the elements of two arrays, stopping at the shorter.
+func zipBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	a, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `zip` must be ARRAY, got %s", args[0].Type())
+	}
+	b, ok := args[1].(*object.Array)
+	if !ok {
+		return newError("argument to `zip` must be ARRAY, got %s", args[1].Type())
+	}
+
+	n := len(a.Elements)
+	if len(b.Elements) < n {
+		n = len(b.Elements)
+	}
+
+	elements := make([]object.Object, n)
+	for i := 0; i < n; i++ {
+		elements[i] = &object.Array{Elements: []object.Object{a.Elements[i], b.Elements[i]}}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// uniq(array) drops the elements equal to an earlier one.
+func uniqBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `uniq` must be ARRAY, got %s", args[0].Type())
+	}
+
+	seen := make(map[object.HashKey]bool)
+	elements := []object.Object{}
+	for _, elem := range arr.Elements {
+		hashKey, ok := elem.(object.Hashable)
+		if !ok {
+			return newError("argument to `uniq` holds an unhashable %s", elem.Type())
+		}
+		if !seen[hashKey.HashKey()] {
+			seen[hashKey.HashKey()] = true
+			elements = append(elements, elem)
+		}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// arrayAndFunction checks the (array, fn) arguments of the builtin name.
+func arrayAndFunction(name string, args []object.Object) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, newError("argument to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+
+	switch args[1].(type) {
+	case *object.Function, *object.Builtin:
+		return arr, args[1], nil
+	default:
+		return nil, nil, newError("function argument to `%s` must be FUNCTION, got %s", name, args[1].Type())
+	}
+}
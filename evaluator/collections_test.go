@@ -0,0 +1,74 @@
+package evaluator
+
+import "testing"
+
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, []interface{}{2, 4, 6}},
+		{`map([], fn(x) { x })`, []interface{}{}},
+		{`map(["ab", "c"], len)`, []interface{}{2, 1}},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, []interface{}{3, 4}},
+		{`reduce([1, 2, 3], 10, fn(acc, x) { acc + x })`, 16},
+		{`reduce([], 0, fn(acc, x) { acc + x })`, 0},
+		{`sort_by([3, 1, 2], fn(x) { x })`, []interface{}{1, 2, 3}},
+		{`sort_by(["bb", "a", "cc", "d"], len)`, []interface{}{"a", "d", "bb", "cc"}},
+		{`sort_by(["b", "a"], fn(x) { x })`, []interface{}{"a", "b"}},
+		{`sort_by([2, 1.5, 9223372036854775808, -1], fn(x) { x })`, []interface{}{-1, 1.5, 2, bigInteger("9223372036854775808")}},
+		{`len(group_by([1, 2, 3, 4, 5], fn(x) { x > 2 }))`, 2},
+		{`group_by([1, 2, 3, 4, 5], fn(x) { x > 2 })[false]`, []interface{}{1, 2}},
+		{`group_by([1, 2, 3, 4, 5], fn(x) { x > 2 })[true]`, []interface{}{3, 4, 5}},
+		{`any([1, 2, 3], fn(x) { x > 2 })`, true},
+		{`any([], fn(x) { true })`, false},
+		{`all([1, 2, 3], fn(x) { x > 2 })`, false},
+		{`all([], fn(x) { false })`, true},
+		{`zip([1, 2, 3], ["a", "b"])`, []interface{}{[]interface{}{1, "a"}, []interface{}{2, "b"}}},
+		{`uniq([1, "a", 1, true, "a", "1"])`, []interface{}{1, "a", true, "1"}},
+
+		{`map(1, fn(x) { x })`, errorMessage("argument to `map` must be ARRAY, got INTEGER")},
+		{`filter([1], 1)`, errorMessage("function argument to `filter` must be FUNCTION, got INTEGER")},
+		{`reduce([1], fn(a, x) { a })`, errorMessage("wrong number of arguments. got=2, want=3")},
+		{`map([1], fn(x, y) { x })`, errorMessage("wrong number of arguments. got=1, want=2")},
+		{`map([1, 2], fn(x) { x + missing })`, errorMessage("identifier not found: missing")},
+		{`filter([1], fn(x) { if (true) { return -x; } })`, []interface{}{1}},
+		{`sort_by([1, "a"], fn(x) { x })`, errorMessage("keys of `sort_by` must all have the same type, got INTEGER and STRING")},
+		{`sort_by([[1]], fn(x) { x })`, errorMessage("keys of `sort_by` must be numbers or STRING, got ARRAY")},
+		{`group_by([1], fn(x) { [x] })`, errorMessage("unusable as hash key: ARRAY")},
+		{`zip([1], 2)`, errorMessage("argument to `zip` must be ARRAY, got INTEGER")},
+		{`uniq([[1]])`, errorMessage("argument to `uniq` holds an unhashable ARRAY")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestCollectionBuiltinsOnVocabulary(t *testing.T) {
+	input := `
+word: "boato" {"Del lat. boatus."};
+word: "vodevil";
+word: "quid" {"Del lat. quid."};
+word: "arenga" {"Quizá del occit. arenga."};
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`map(filter(words(), fn(w) { len(w.definition) == 0 }), fn(w) { w.name })`, []interface{}{"vodevil"}},
+		{`map(sort_by(words(), fn(w) { w.name }), fn(w) { w.name })`, []interface{}{"arenga", "boato", "quid", "vodevil"}},
+		{`all(words(), fn(w) { exists(w.name) })`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
@@ -1,489 +1,916 @@
-package evaluator
-
-import (
-	"fmt"
-	"wordbuilder/ast"
-	"wordbuilder/object"
-)
-
-var (
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
-	NULL  = &object.Null{}
-)
-
-func isError(obj object.Object) bool {
-	if obj != nil {
-		return obj.Type() == object.ErrorObj
-	}
-	return false
-}
-
-func Eval(node ast.Node, env *object.Environment) object.Object {
-	switch node := node.(type) {
-
-	case *ast.Program:
-		return evalProgram(node, env)
-
-	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
-
-	case *ast.IntegerLiteral:
-		return &object.Integer{Value: node.Value}
-
-	case *ast.Boolean:
-		return nativeBoolToBooleanIObject(node.Value)
-
-	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
-		if isError(right) {
-			return right
-		}
-		return evalPrefixExpression(node.Operator, right)
-
-	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-
-		right := Eval(node.Right, env)
-		if isError(right) {
-			return right
-		}
-
-		return evalInfixExpression(node.Operator, left, right)
-
-	case *ast.FunctionLiteral:
-		params := node.Parameters
-		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
-
-	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
-
-	case *ast.StringLiteral:
-		return &object.String{Value: node.Value}
-
-	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
-			return elements[0]
-		}
-		return &object.Array{Elements: elements}
-
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-		return evalIndexExpression(left, index)
-
-	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
-
-	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-		return applyFunction(env, function, args)
-
-	case *ast.LetStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		// Adding a new variable or changing an existing one.
-		env.Set(node.Name.Value, val)
-
-	case *ast.Identifier:
-		return evalIdentifier(node, env)
-
-	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
-		if isError(val) {
-			return val
-		}
-		return &object.ReturnValue{Value: val}
-
-	case *ast.IfExpression:
-		return evalIfExpression(node, env)
-
-	case *ast.ReferenceStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		obj := &object.Reference{Ref: node.Name.Value}
-
-		if val == nil {
-			obj.Definition = ""
-		} else {
-			obj.Definition = val.Inspect()
-		}
-
-		env.Set(node.Name.Value, obj)
-
-		return obj
-
-	case *ast.TranslationStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		obj := &object.Translation{Translation: node.Name.Value}
-
-		if val == nil {
-			obj.Definition = ""
-		} else {
-			obj.Definition = val.Inspect()
-		}
-
-		env.Set(node.Name.Value, obj)
-
-		return obj
-
-	case *ast.WordStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-
-		obj := &object.Word{Word: node.Name.Value}
-
-		if val == nil {
-			obj.Definition = ""
-		} else {
-			obj.Definition = val.Inspect()
-		}
-
-		env.Set(node.Name.Value, obj)
-
-		return obj
-
-	case *ast.MeThoughtStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-
-		obj := &object.MeThought{Thought: node.Content}
-		env.AddThought(node.Content)
-
-		return obj
-
-	case *ast.QuoteStatement:
-		obj := &object.Quote{By: node.By, Text: node.Text}
-		env.AddQuote(*obj)
-
-		return obj
-
-	case *ast.ConceptStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		obj := &object.Concept{Concept: node.Name.Value}
-
-		if val == nil {
-			obj.Definition = ""
-		} else {
-			obj.Definition = val.Inspect()
-		}
-
-		env.Set(node.Name.Value, obj)
-
-		return obj
-	}
-	return nil
-}
-
-func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
-
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
-		if isError(key) {
-			return key
-		}
-
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
-		}
-
-		value := Eval(valueNode, env)
-		if isError(value) {
-			return value
-		}
-
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
-	}
-
-	return &object.Hash{Pairs: pairs}
-}
-
-func evalIndexExpression(left, index object.Object) object.Object {
-	switch {
-	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
-		return evalArrayIndexExpression(left, index)
-	case left.Type() == object.HashObj:
-		return evalHashIndexExpression(left, index)
-	default:
-		return newError("index operator not supported: %s", left.Type())
-	}
-}
-
-func evalHashIndexExpression(hash, index object.Object) object.Object {
-	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
-
-	if !ok {
-		return newError("unusable as hash key: %s", index.Type())
-	}
-
-	pair, ok := hashObject.Pairs[key.HashKey()]
-	if !ok {
-		return NULL
-	}
-
-	return pair.Value
-}
-
-func evalArrayIndexExpression(array, index object.Object) object.Object {
-	arrayObject := array.(*object.Array)
-	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObject.Elements) - 1)
-
-	if idx < 0 || idx > max {
-		return NULL
-	}
-
-	return arrayObject.Elements[idx]
-}
-
-func applyFunction(env *object.Environment, fn object.Object, args []object.Object) object.Object {
-
-	switch fn := fn.(type) {
-	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
-
-	case *object.Builtin:
-		return fn.Fn(env, args...)
-
-	default:
-		return newError("not a function: %s", fn.Type())
-	}
-}
-
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
-
-	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
-	}
-	return env
-}
-
-func unwrapReturnValue(obj object.Object) object.Object {
-	if returnValue, ok := obj.(*object.ReturnValue); ok {
-		return returnValue.Value
-	}
-	return obj
-}
-
-func evalExpressions(
-	exps []ast.Expression, env *object.Environment,
-) []object.Object {
-	var result []object.Object
-	for _, e := range exps {
-		evaluated := Eval(e, env)
-		if isError(evaluated) {
-			return []object.Object{evaluated}
-		}
-		result = append(result, evaluated)
-	}
-	return result
-}
-
-func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	if val, ok := env.Get(node.Value); ok {
-		return val
-	}
-	if builtin, ok := builtins[node.Value]; ok {
-		return builtin
-	}
-	return newError("identifier not found: " + node.Value)
-}
-
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
-	if isError(condition) {
-		return condition
-	}
-	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
-	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
-	} else {
-		return NULL
-	}
-}
-
-func isTruthy(obj object.Object) bool {
-	switch obj {
-	case NULL:
-		return false
-	case TRUE:
-		return true
-	case FALSE:
-		return false
-	default:
-		return true
-	}
-}
-
-func evalInfixExpression(operator string, left, right object.Object) object.Object {
-	switch {
-	case left.Type() == object.IntegerObj && right.Type() == object.IntegerObj:
-		return evalIntegerInfixExpression(operator, left, right)
-
-	case left.Type() == object.StringObj && right.Type() == object.StringObj:
-		return evalStringInfixExpression(operator, left, right)
-	case operator == "==":
-		return nativeBoolToBooleanIObject(left == right)
-	case operator == "!=":
-		return nativeBoolToBooleanIObject(left != right)
-	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s",
-			left.Type(), operator, right.Type())
-	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-}
-
-func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s",
-			left.Type(), operator, right.Type())
-	}
-
-	leftVal := left.(*object.String).Value
-	rightVal := right.(*object.String).Value
-
-	return &object.String{Value: leftVal + rightVal}
-}
-
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Integer).Value
-	rightVal := right.(*object.Integer).Value
-
-	switch operator {
-	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
-	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
-	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
-	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
-	case "<":
-		return nativeBoolToBooleanIObject(leftVal < rightVal)
-	case ">":
-		return nativeBoolToBooleanIObject(leftVal > rightVal)
-	case "==":
-		return nativeBoolToBooleanIObject(leftVal == rightVal)
-	case "!=":
-		return nativeBoolToBooleanIObject(leftVal != rightVal)
-	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
-	}
-
-}
-
-func evalPrefixExpression(operator string, right object.Object) object.Object {
-	switch operator {
-	case "!":
-		return evalBangOperator(right)
-	case "-":
-		return evalMinusPrefixOperatorExpression(right)
-	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
-	}
-}
-
-func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.IntegerObj {
-		return newError("unknown operator: -%s", right.Type())
-	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
-}
-
-func evalBangOperator(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
-	case NULL:
-		return TRUE
-	default:
-		return FALSE
-	}
-}
-
-func nativeBoolToBooleanIObject(input bool) *object.Boolean {
-	if input {
-		return TRUE
-	}
-	return FALSE
-}
-
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
-	var result object.Object
-
-	for _, statement := range program.Statements {
-		result = Eval(statement, env)
-		switch result := result.(type) {
-		case *object.ReturnValue:
-			return result.Value
-		case *object.Error:
-			return result
-		}
-	}
-	return result
-}
-
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
-	var result object.Object
-
-	for _, statement := range block.Statements {
-		result = Eval(statement, env)
-		if result != nil {
-			rt := result.Type()
-			if rt == object.ReturnValueObj || rt == object.ErrorObj {
-				return result
-			}
-		}
-	}
-
-	return result
-}
-
-func newError(format string, a ...interface{}) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, a...)}
-}
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"wordbuilder/ast"
+	"wordbuilder/object"
+	"wordbuilder/token"
+)
+
+var (
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
+)
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ErrorObj
+	}
+	return false
+}
+
+// Eval evaluates node in env. Errors raised while evaluating it record the
+// position of the innermost node they came from. A panic in the evaluator is
+// turned into an error rather than crashing the interpreter.
+func Eval(node ast.Node, env *object.Environment) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = newError("internal error: %v", r)
+		}
+		if err, ok := result.(*object.Error); ok {
+			withPosition(err, node)
+		}
+	}()
+
+	return evalNode(node, env)
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
+	switch node := node.(type) {
+
+	case *ast.Program:
+		return evalProgram(node, env)
+
+	case *ast.ExpressionStatement:
+		return Eval(node.Expression, env)
+
+	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInteger{Value: node.Big}
+		}
+		return &object.Integer{Value: node.Value}
+
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.Boolean:
+		return nativeBoolToBooleanIObject(node.Value)
+
+	case *ast.PrefixExpression:
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+
+	case *ast.InfixExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+
+		// && and || only evaluate their right operand if they need it.
+		switch {
+		case node.Operator == "&&" && !isTruthy(left):
+			return FALSE
+		case node.Operator == "||" && isTruthy(left):
+			return TRUE
+		}
+
+		right := Eval(node.Right, env)
+		if isError(right) {
+			return right
+		}
+
+		return evalInfixExpression(node.Operator, left, right)
+
+	case *ast.FunctionLiteral:
+		params := node.Parameters
+		body := node.Body
+		return &object.Function{Parameters: params, Body: body, Env: env}
+
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, env)
+
+	case *ast.StringLiteral:
+		return &object.String{Value: node.Value}
+
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+
+	case *ast.IndexExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(node.Index, env)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
+
+	case *ast.FieldExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		return evalFieldExpression(left, node.Field.Value)
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+
+	case *ast.CallExpression:
+		function := Eval(node.Function, env)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		result := applyFunction(env, function, args)
+		if err, ok := result.(*object.Error); ok {
+			if fn, ok := function.(*object.Function); ok {
+				err.Stack = append(err.Stack, object.Frame{Function: fn.Name, Pos: node.Pos()})
+			}
+		}
+		return result
+
+	case *ast.ImportStatement:
+		result := evalImportStatement(node, env)
+		// Errors raised inside the imported file already carry their
+		// position; record the import that led there.
+		if err, ok := result.(*object.Error); ok && err.Pos.IsValid() {
+			err.Stack = append(err.Stack, object.Frame{Function: "import", Pos: node.Pos()})
+		}
+		return result
+
+	case *ast.LetStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		// Name anonymous functions after their first binding, so that
+		// stack traces can tell them apart.
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			fn.Name = node.Name.Value
+		}
+		// Adding a new variable or changing an existing one.
+		env.Set(node.Name.Value, val)
+
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+
+	case *ast.ReturnStatement:
+		val := Eval(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+
+	case *ast.IfExpression:
+		return evalIfExpression(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.BranchStatement:
+		if node.Token.Type == token.Break {
+			return BREAK
+		}
+		return CONTINUE
+
+	case *ast.ReferenceStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		attrs, err := evalAttributes(node.Attributes, env)
+		if err != nil {
+			return err
+		}
+
+		obj := &object.Reference{Ref: node.Name.Value, Comment: node.Doc, Metadata: attrs}
+
+		if val == nil {
+			obj.Definition = ""
+		} else {
+			obj.Definition = val.Inspect()
+		}
+
+		env.Knowledge().Define(obj)
+
+		return obj
+
+	case *ast.TranslationStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		attrs, err := evalAttributes(node.Attributes, env)
+		if err != nil {
+			return err
+		}
+
+		obj := &object.Translation{Translation: node.Name.Value, Comment: node.Doc, Metadata: attrs}
+
+		if val == nil {
+			obj.Definition = ""
+		} else {
+			obj.Definition = val.Inspect()
+		}
+
+		env.Knowledge().Define(obj)
+
+		return obj
+
+	case *ast.WordStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		attrs, err := evalAttributes(node.Attributes, env)
+		if err != nil {
+			return err
+		}
+
+		obj := &object.Word{Word: node.Name.Value, Comment: node.Doc, Metadata: attrs}
+
+		if val == nil {
+			obj.Definition = ""
+		} else {
+			obj.Definition = val.Inspect()
+		}
+
+		env.Knowledge().Define(obj)
+
+		return obj
+
+	case *ast.MeThoughtStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+
+		obj := &object.MeThought{Thought: node.Content}
+		env.AddThought(node.Content)
+
+		return obj
+
+	case *ast.QuoteStatement:
+		attrs, err := evalAttributes(node.Attributes, env)
+		if err != nil {
+			return err
+		}
+
+		obj := &object.Quote{By: node.By, Text: node.Text, Attributes: attrs}
+		env.AddQuote(*obj)
+
+		return obj
+
+	case *ast.ConceptStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		attrs, err := evalAttributes(node.Attributes, env)
+		if err != nil {
+			return err
+		}
+
+		obj := &object.Concept{Concept: node.Name.Value, Comment: node.Doc, Metadata: attrs}
+
+		if val == nil {
+			obj.Definition = ""
+		} else {
+			obj.Definition = val.Inspect()
+		}
+
+		env.Knowledge().Define(obj)
+
+		return obj
+	}
+	return nil
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	for keyNode, valueNode := range node.Pairs {
+		key := Eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(valueNode, env)
+		if isError(value) {
+			return value
+		}
+
+		hashed := hashKey.HashKey()
+		pairs[hashed] = object.HashPair{Key: key, Value: value}
+	}
+
+	return &object.Hash{Pairs: pairs}
+}
+
+func evalIndexExpression(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
+		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.StringObj && index.Type() == object.IntegerObj:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.HashObj:
+		return evalHashIndexExpression(left, index)
+	default:
+		return newError("index operator not supported: %s", left.Type())
+	}
+}
+
+func evalHashIndexExpression(hash, index object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+	key, ok := index.(object.Hashable)
+
+	if !ok {
+		return newError("unusable as hash key: %s", index.Type())
+	}
+
+	pair, ok := hashObject.Pairs[key.HashKey()]
+	if !ok {
+		return NULL
+	}
+
+	return pair.Value
+}
+
+func evalArrayIndexExpression(array, index object.Object) object.Object {
+	arrayObject := array.(*object.Array)
+	idx := index.(*object.Integer).Value
+	max := int64(len(arrayObject.Elements) - 1)
+
+	if idx < 0 || idx > max {
+		return NULL
+	}
+
+	return arrayObject.Elements[idx]
+}
+
+// evalFieldExpression looks up field on an entry, a quote or a hash. Hash
+// fields are string keys, so h.lang is h["lang"].
+func evalFieldExpression(left object.Object, field string) object.Object {
+	switch left := left.(type) {
+	case object.Entry:
+		switch field {
+		case "name":
+			return &object.String{Value: left.Name()}
+		case "definition":
+			return &object.String{Value: left.Text()}
+		case "kind":
+			return &object.String{Value: strings.ToLower(string(left.Type()))}
+		case "doc":
+			return &object.String{Value: left.Doc()}
+		case "attributes":
+			return left.Attributes().Hash()
+		}
+	case *object.Quote:
+		switch field {
+		case "by":
+			return &object.String{Value: left.By}
+		case "text":
+			return &object.String{Value: left.Text}
+		case "attributes":
+			return left.Attributes.Hash()
+		}
+	case *object.Hash:
+		return evalHashIndexExpression(left, &object.String{Value: field})
+	default:
+		return newError("field access not supported: %s", left.Type())
+	}
+	return newError("unknown field %s of %s", field, left.Type())
+}
+
+// evalStringIndexExpression returns the character at index, counting in
+// characters rather than bytes.
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+
+	if idx < 0 || idx >= int64(len(runes)) {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+func applyFunction(env *object.Environment, fn object.Object, args []object.Object) object.Object {
+
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments. got=%d, want=%d",
+				len(args), len(fn.Parameters))
+		}
+		extendedEnv := extendFunctionEnv(fn, args)
+		evaluated := Eval(fn.Body, extendedEnv)
+		return unwrapReturnValue(evaluated)
+
+	case *object.Builtin:
+		return fn.Fn(env, args...)
+
+	default:
+		return newError("not a function: %s", fn.Type())
+	}
+}
+
+func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+	env := object.NewEnclosedEnvironment(fn.Env)
+
+	for paramIdx, param := range fn.Parameters {
+		env.Set(param.Value, args[paramIdx])
+	}
+	return env
+}
+
+// evalAttributes evaluates the metadata of an entry. It returns the error of
+// the first value that fails, if any.
+func evalAttributes(list *ast.AttributeList, env *object.Environment) (object.Attributes, object.Object) {
+	if list == nil {
+		return nil, nil
+	}
+
+	attrs := object.Attributes{}
+	for _, attr := range list.Attributes {
+		val := Eval(attr.Value, env)
+		if isError(val) {
+			return nil, val
+		}
+		attrs = append(attrs, object.Attribute{Key: attr.Key.Value, Value: val})
+	}
+	return attrs, nil
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	// A function with an empty body returns null.
+	if obj == nil {
+		return NULL
+	}
+	return obj
+}
+
+func evalExpressions(
+	exps []ast.Expression, env *object.Environment,
+) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	if entry, ok := env.Knowledge().Lookup(node.Value); ok {
+		return entry
+	}
+	if builtin, ok := builtins[node.Value]; ok {
+		return builtin
+	}
+	return newError("identifier not found: %s", node.Value)
+}
+
+func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := Eval(ie.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+	if isTruthy(condition) {
+		return Eval(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return Eval(ie.Alternative, env)
+	} else {
+		return NULL
+	}
+}
+
+// evalForStatement runs the body of a for-in loop for each element of an
+// array, or each pair of a hash in key order. Every iteration gets a scope
+// of its own for the loop variables.
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var keys, values []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		values = iterable.Elements
+		for i := range values {
+			keys = append(keys, &object.Integer{Value: int64(i)})
+		}
+	case *object.Hash:
+		for _, pair := range iterable.SortedPairs() {
+			keys = append(keys, pair.Key)
+			values = append(values, pair.Value)
+		}
+		if fs.Key == nil {
+			values = keys // a single variable takes the keys
+		}
+	default:
+		return withPosition(newError("cannot iterate over %s", iterable.Type()), fs.Iterable)
+	}
+
+	for i := range values {
+		vars := map[string]object.Object{fs.Value.Value: values[i]}
+		if fs.Key != nil {
+			vars[fs.Key.Value] = keys[i]
+		}
+
+		result := Eval(fs.Body, object.NewLoopEnvironment(env, vars))
+		if result != nil {
+			switch result.Type() {
+			case object.BreakObj:
+				return nil
+			case object.ReturnValueObj, object.ErrorObj:
+				return result
+			}
+		}
+	}
+	return nil
+}
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		result := Eval(ws.Body, env)
+		if result != nil {
+			switch result.Type() {
+			case object.BreakObj:
+				return nil
+			case object.ReturnValueObj, object.ErrorObj:
+				return result
+			}
+		}
+	}
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+	case TRUE:
+		return true
+	case FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+// evalInterpolatedString joins the text of the string with the values of
+// its embedded expressions, converted as by str().
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+	for _, part := range node.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+		if str, ok := val.(*object.String); ok {
+			out.WriteString(str.Value)
+		} else if val != nil {
+			out.WriteString(val.Inspect())
+		}
+	}
+	return &object.String{Value: out.String()}
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case operator == "&&" || operator == "||":
+		return nativeBoolToBooleanIObject(isTruthy(right))
+	case left.Type() == object.IntegerObj && right.Type() == object.IntegerObj:
+		return evalIntegerInfixExpression(operator, left, right)
+	case object.IsNumber(left) && object.IsNumber(right):
+		if left.Type() == object.FloatObj || right.Type() == object.FloatObj {
+			return evalFloatInfixExpression(operator, left, right)
+		}
+		return evalBigIntegerInfixExpression(operator, object.ToBig(left), object.ToBig(right))
+
+	case left.Type() == object.StringObj && right.Type() == object.StringObj:
+		return evalStringInfixExpression(operator, left, right)
+	case operator == "==":
+		return nativeBoolToBooleanIObject(object.Equal(left, right))
+	case operator == "!=":
+		return nativeBoolToBooleanIObject(!object.Equal(left, right))
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s",
+			left.Type(), operator, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// evalStringInfixExpression concatenates strings, and compares them byte by
+// byte, which orders them by code point.
+func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanIObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanIObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanIObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanIObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanIObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanIObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	if overflows(operator, leftVal, rightVal) {
+		return evalBigIntegerInfixExpression(operator, big.NewInt(leftVal), big.NewInt(rightVal))
+	}
+
+	switch operator {
+	case "+":
+		return &object.Integer{Value: leftVal + rightVal}
+	case "-":
+		return &object.Integer{Value: leftVal - rightVal}
+	case "*":
+		return &object.Integer{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero: %d %% %d", leftVal, rightVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "<":
+		return nativeBoolToBooleanIObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanIObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanIObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanIObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanIObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanIObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+}
+
+// overflows reports whether the integer operation does not fit in an int64.
+func overflows(operator string, a, b int64) bool {
+	switch operator {
+	case "+":
+		return (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b)
+	case "-":
+		return (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b)
+	case "*":
+		if a == 0 || b == 0 {
+			return false
+		}
+		c := a * b
+		return c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64)
+	case "/":
+		return a == math.MinInt64 && b == -1
+	}
+	return false
+}
+
+// evalBigIntegerInfixExpression works on integers of any size. Its results
+// are Integers again whenever they fit.
+func evalBigIntegerInfixExpression(operator string, left, right *big.Int) object.Object {
+	switch operator {
+	case "+":
+		return object.NewInteger(new(big.Int).Add(left, right))
+	case "-":
+		return object.NewInteger(new(big.Int).Sub(left, right))
+	case "*":
+		return object.NewInteger(new(big.Int).Mul(left, right))
+	case "/":
+		if right.Sign() == 0 {
+			return newError("division by zero: %s / %s", left, right)
+		}
+		return object.NewInteger(new(big.Int).Quo(left, right))
+	case "%":
+		if right.Sign() == 0 {
+			return newError("division by zero: %s %% %s", left, right)
+		}
+		return object.NewInteger(new(big.Int).Rem(left, right))
+	}
+	return compareOrder(operator, left.Cmp(right), object.IntegerObj)
+}
+
+// evalFloatInfixExpression works on a float and a number of any kind.
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := object.ToFloat(left)
+	rightVal := object.ToFloat(right)
+
+	switch operator {
+	case "+":
+		return floatResult(leftVal+rightVal, operator, left, right)
+	case "-":
+		return floatResult(leftVal-rightVal, operator, left, right)
+	case "*":
+		return floatResult(leftVal*rightVal, operator, left, right)
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return floatResult(leftVal/rightVal, operator, left, right)
+	case "%":
+		if rightVal == 0 {
+			return newError("division by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return floatResult(math.Mod(leftVal, rightVal), operator, left, right)
+	case "<", ">", "<=", ">=", "==", "!=":
+		// NaN is unordered: only != holds.
+		if math.IsNaN(leftVal) || math.IsNaN(rightVal) {
+			return nativeBoolToBooleanIObject(operator == "!=")
+		}
+		return compareOrder(operator, object.CompareNumbers(left, right), object.FloatObj)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// floatResult returns value as a FLOAT, or an error if the operation
+// overflowed: FLOATs are always finite, so that they can be written back as
+// literals.
+func floatResult(value float64, operator string, left, right object.Object) object.Object {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return newError("float overflow: %s %s %s", left.Inspect(), operator, right.Inspect())
+	}
+	return &object.Float{Value: value}
+}
+
+// compareOrder evaluates a comparison operator given the result of Cmp.
+func compareOrder(operator string, cmp int, t object.Type) object.Object {
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanIObject(cmp < 0)
+	case ">":
+		return nativeBoolToBooleanIObject(cmp > 0)
+	case "<=":
+		return nativeBoolToBooleanIObject(cmp <= 0)
+	case ">=":
+		return nativeBoolToBooleanIObject(cmp >= 0)
+	case "==":
+		return nativeBoolToBooleanIObject(cmp == 0)
+	case "!=":
+		return nativeBoolToBooleanIObject(cmp != 0)
+	default:
+		return newError("unknown operator: %s %s %s", t, operator, t)
+	}
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return evalBangOperator(right)
+	case "-":
+		return evalMinusPrefixOperatorExpression(right)
+	default:
+		return newError("unknown operator: %s%s", operator, right.Type())
+	}
+}
+
+func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInteger:
+		return object.NewInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
+		return newError("unknown operator: -%s", right.Type())
+	}
+}
+
+func evalBangOperator(right object.Object) object.Object {
+	switch right {
+	case TRUE:
+		return FALSE
+	case FALSE:
+		return TRUE
+	case NULL:
+		return TRUE
+	default:
+		return FALSE
+	}
+}
+
+func nativeBoolToBooleanIObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range program.Statements {
+		result = Eval(statement, env)
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+		if result != nil {
+			rt := result.Type()
+			if rt == object.ReturnValueObj || rt == object.ErrorObj ||
+				rt == object.BreakObj || rt == object.ContinueObj {
+				return result
+			}
+		}
+	}
+
+	return result
+}
+
+// withPosition records where err was raised unless it already knows it.
+func withPosition(err *object.Error, node ast.Node) *object.Error {
+	if !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+	return err
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
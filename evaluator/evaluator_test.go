@@ -1,749 +1,1466 @@
-package evaluator
-
-import (
-	"fmt"
-	"testing"
-	"wordbuilder/lexer"
-	"wordbuilder/object"
-	"wordbuilder/parser"
-)
-
-func TestEvalInteger(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"5", 5},
-		{"10", 10},
-		{"-5", -5},
-		{"-10", -10},
-		{"5 + 5 + 5 + 5 - 10", 10},
-		{"2 * 2 * 2 * 2 * 2", 32},
-		{"-50 + 100 + -50", 0},
-		{"5 * 2 + 10", 20},
-		{"5 + 2 * 10", 25},
-		{"20 + 2 * -10", 0},
-		{"50 / 2 * 2 + 10", 60},
-		{"2 * (5 + 10)", 30},
-		{"3 * 3 * 3 + 10", 37},
-		{"3 * (3 * 3) + 10", 37},
-		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testIntegerObject(t, evaluated, tt.expected)
-	}
-
-}
-
-func testEval(input string) object.Object {
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnvironment()
-	return Eval(program, env)
-}
-
-func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
-	result, ok := obj.(*object.Integer)
-	if !ok {
-		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
-		return false
-	}
-
-	if result.Value != expected {
-		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
-		return false
-	}
-
-	return true
-}
-
-func TestEvalBooleanExpression(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected bool
-	}{
-		{"true", true},
-		{"false", false},
-		{"1 < 2", true},
-		{"1 > 2", false},
-		{"1 < 1", false},
-		{"1 > 1", false},
-		{"1 == 1", true},
-		{"1 != 1", false},
-		{"1 == 2", false},
-		{"1 != 2", true},
-		{"true == true", true},
-		{"false == false", true},
-		{"true == false", false},
-		{"true != false", true},
-		{"false != true", true},
-		{"(1 < 2) == true", true},
-		{"(1 < 2) == false", false},
-		{"(1 > 2) == true", false},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testBooleanObject(t, evaluated, tt.expected)
-	}
-}
-
-func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
-	result, ok := obj.(*object.Boolean)
-	if !ok {
-		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
-		return false
-	}
-
-	if result.Value != expected {
-		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
-		return false
-	}
-
-	return true
-}
-
-func TestBangOperator(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected bool
-	}{
-		{"!true", false},
-		{"!false", true},
-		{"!5", false},
-		{"!!true", true},
-		{"!!false", false},
-		{"!(true)", false},
-		{"!!5", true},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testBooleanObject(t, evaluated, tt.expected)
-	}
-
-}
-
-func TestIfElseExpressions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{"if (true) { 10 }", 10},
-		{"if (false) { 10 }", nil},
-		{"if (1) { 10 }", 10},
-		{"if (1 < 2) { 10 }", 10},
-		{"if (1 > 2) { 10 }", nil},
-		{"if (1 > 2) { 10 } else { 20 }", 20},
-		{"if (1 < 2) { 10 } else { 20 }", 10},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
-		}
-	}
-}
-
-func testNullObject(t *testing.T, obj object.Object) bool {
-	return obj == NULL
-}
-
-func TestReturnStatements(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"return 10;", 10},
-		{"return 10; 9;", 10},
-		{"return 2 * 5; 9;", 10},
-		{"9; return 2 * 5; 9;", 10},
-		{
-			`
-if (10 > 1) {
-  if (10 > 1) {
-    return 10;
-  }
-
-  return 1;
-}
-`, 10,
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testIntegerObject(t, evaluated, tt.expected)
-	}
-}
-
-func TestErrorHandling(t *testing.T) {
-	tests := []struct {
-		input           string
-		expectedMessage string
-	}{
-		{
-			"5 + true;",
-			"type mismatch: INTEGER + BOOLEAN",
-		},
-		{
-			"5 + true; 5;",
-			"type mismatch: INTEGER + BOOLEAN",
-		},
-		{
-			"-true",
-			"unknown operator: -BOOLEAN",
-		},
-		{
-			"true + false;",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"5; true + false; 5",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"if (10 > 1) { true + false; }",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			`
-if (10 > 1) {
-  if (10 > 1) {
-    return true + false;
-  }
-
-  return 1;
-}
-`,
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"foobar",
-			"identifier not found: foobar",
-		},
-		{
-			`"Hello" - "World"`,
-			"unknown operator: STRING - STRING",
-		},
-		{
-			`{"name": "Monkey"}[fn(x) { x }];`,
-			"unusable as hash key: FUNCTION",
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-
-		errObj, ok := evaluated.(*object.Error)
-		if !ok {
-			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
-			continue
-		}
-
-		if errObj.Message != tt.expectedMessage {
-			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
-		}
-	}
-
-}
-
-func TestLetstatements(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"let a = 5; a;", 5},
-		{"let a = 5 * 5; a;", 25},
-		{"let a = 5; let b = a; b;", 5},
-		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
-	}
-
-	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
-	}
-}
-
-func TestFunctionObject(t *testing.T) {
-	input := "fn(x) { x + 2; };"
-
-	evaluated := testEval(input)
-	fn, ok := evaluated.(*object.Function)
-	if !ok {
-		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if len(fn.Parameters) != 1 {
-		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
-	}
-
-	if fn.Parameters[0].String() != "x" {
-		t.Fatalf("parameters is not 'x'. got=%q", fn.Parameters[0])
-	}
-
-	expectedBody := "(x + 2)"
-
-	if fn.Body.String() != expectedBody {
-		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
-	}
-}
-
-func TestFunctionApplication(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected int64
-	}{
-		{"let identity = fn(x) { x; }; identity(5);", 5},
-		{"let identity = fn(x) { return x; }; identity(5);", 5},
-		{"let double = fn(x) { x * 2; }; double(5);", 10},
-		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
-		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
-		{"fn(x) { x; }(5)", 5},
-	}
-
-	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
-	}
-}
-
-func TestClosures(t *testing.T) {
-	input := `
-	let newAdder = fn(x) {
-		fn(y) { x + y };
-	};
-
-	let addTwo = newAdder(2);
-	addTwo(2);
-	`
-	testIntegerObject(t, testEval(input), 4)
-}
-
-func TestStringLiteral(t *testing.T) {
-	input := `"Hello World!"`
-
-	evaluated := testEval(input)
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not a String. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if str.Value != "Hello World!" {
-		t.Errorf("String has wrong value. got=%q", str.Value)
-	}
-}
-
-func TestStringConcatenation(t *testing.T) {
-	input := `"Hello" + " " + "World!"`
-	evaluated := testEval(input)
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if str.Value != "Hello World!" {
-		t.Errorf("String has wrong value. got=%q", str.Value)
-	}
-}
-
-func TestBuiltinFunctions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{`len("")`, 0},
-		{`len("four")`, 4},
-		{`len("hello world")`, 11},
-		{`max(3, 5, 2)`, 5},
-		{`max(2)`, 2},
-		{`exists("alv")`, false},
-		{`
-		word: "cultual" {"Perteneciente o relativo al culto religioso."};
-		defined("cultual");
-		`, true},
-		{`len(1)`, "argument to `len` not supported, got INTEGER"},
-		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
-		{`len({
-			"one": 10,
-			"two": 1
-		})`, 2},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		switch expected := tt.expected.(type) {
-		case int:
-			testIntegerObject(t, evaluated, int64(expected))
-		case string:
-			errObj, ok := evaluated.(*object.Error)
-			if !ok {
-				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
-				continue
-			}
-			if errObj.Message != expected {
-				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
-			}
-		case bool:
-			ok, _ := tt.expected.(bool)
-			fmt.Println(ok)
-			testBooleanObject(t, evaluated, ok)
-		}
-	}
-}
-
-func TestArrayLiterals(t *testing.T) {
-	input := "[1, 2 * 2, 3 + 3]"
-
-	evaluated := testEval(input)
-	result, ok := evaluated.(*object.Array)
-	if !ok {
-		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if len(result.Elements) != 3 {
-		t.Fatalf("array has wrong num of elements. got=%d",
-			len(result.Elements))
-	}
-
-	testIntegerObject(t, result.Elements[0], 1)
-	testIntegerObject(t, result.Elements[1], 4)
-	testIntegerObject(t, result.Elements[2], 6)
-}
-
-func TestArrayIndexExpressions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{
-			"[1, 2, 3][0]",
-			1,
-		},
-		{
-			"[1, 2, 3][1]",
-			2,
-		},
-		{
-			"[1, 2, 3][2]",
-			3,
-		},
-		{
-			"let i = 0; [1][i];",
-			1,
-		},
-		{
-			"[1, 2, 3][1 + 1];",
-			3,
-		},
-		{
-			"let myArray = [1, 2, 3]; myArray[2];",
-			3,
-		},
-		{
-			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
-			6,
-		},
-		{
-			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
-			2,
-		},
-		{
-			"[1, 2, 3][3]",
-			nil,
-		},
-		{
-			"[1, 2, 3][-1]",
-			nil,
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
-		}
-	}
-}
-
-func TestHashLiterals(t *testing.T) {
-	input := `let two = "two";
-    {
-        "one": 10 - 9,
-        two: 1 + 1,
-        "thr" + "ee": 6 / 2,
-        4: 4,
-        true: 5,
-        false: 6
-	}`
-
-	evaluated := testEval(input)
-	result, ok := evaluated.(*object.Hash)
-	if !ok {
-		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
-	}
-
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
-	}
-
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
-		if !ok {
-			t.Errorf("no pair for given key in Pairs")
-		}
-
-		testIntegerObject(t, pair.Value, expectedValue)
-	}
-}
-
-func TestWordAddition(t *testing.T) {
-	input := `word: "bulo" {"burla"};
-	bulo;
-	`
-
-	evaluated := testEval(input)
-
-	w, ok := evaluated.(*object.Word)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if w.Word != "bulo" {
-		t.Fatalf("object is not '%s', got '%s'", "bulo", w.Word)
-	}
-
-	if w.Definition != "burla" {
-		t.Fatalf("object is not '%s', got '%s'", "burla", w.Word)
-	}
-}
-
-func TestQuoteAddition(t *testing.T) {
-	input := `quote: "Byung-Chul Han" {"Some text ... "};`
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnvironment()
-	Eval(program, env)
-
-	if len(env.Quotes()) < 1 {
-		t.Fatal("quotes are empty ... ")
-	}
-
-	quote := env.Quotes()[0]
-	if quote.By != "Byung-Chul Han" {
-		t.Errorf("quote author wrong, got='%s', expected: '%s'", quote.By, "Byung-Chul Han")
-	}
-
-	if quote.Text != "Some text ... " {
-		t.Errorf("quote text wrong, got='%s', expected: '%s'", quote.Text, "Some text ... ")
-	}
-}
-
-func TestRefAddition(t *testing.T) {
-	input := `ref: "a" {"b"};
-	a;
-	`
-
-	evaluated := testEval(input)
-
-	ref, ok := evaluated.(*object.Reference)
-	if !ok {
-		t.Fatalf("object is not Reference. got=%T (%+v)", evaluated, evaluated)
-	}
-	if ref.Ref != "a" {
-		t.Fatalf("object is not '%s', got '%s'", "a", ref.Ref)
-	}
-
-	if ref.Definition != "b" {
-		t.Fatalf("object is not '%s', got '%s'", "b", ref.Definition)
-	}
-}
-
-func TestConceptAddition(t *testing.T) {
-	input := `cpt: "a" {"b"};
-	a
-	`
-
-	evaluated := testEval(input)
-
-	cpt, ok := evaluated.(*object.Concept)
-	if !ok {
-		t.Fatalf("object is not Concept. got=%T (%+v)", evaluated, evaluated)
-	}
-	if cpt.Concept != "a" {
-		t.Fatalf("object is not '%s', got '%s'", "a", cpt.Concept)
-	}
-
-	if cpt.Definition != "b" {
-		t.Fatalf("object is not '%s', got '%s'", "b", cpt.Concept)
-	}
-}
-
-func TestMeThoughtAddition(t *testing.T) {
-	input := `me: {"pensamiento"};`
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnvironment()
-	Eval(program, env)
-
-	if len(env.Thoughts()) < 1 {
-		t.Fatal("thoughts are empty ... ")
-	}
-
-	thought := env.Thoughts()[0]
-	if thought != "pensamiento" {
-		t.Errorf("thought wrong, got='%s', expected: '%s'", thought, "pensamiento")
-	}
-
-}
-
-func TestThoughtsFunctionWithExpression(t *testing.T) {
-	input := `
-	me: {"pensamiento"};
-	let x = thoughts()[0];
-	x
-	`
-
-	evaluated := testEval(input)
-
-	str, ok := evaluated.(*object.String)
-	if !ok {
-		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if str.Value != "pensamiento" {
-		t.Fatalf("object is not '%s', got '%s'", "pensamiento", str.Value)
-	}
-
-}
-
-func TestThoughtsFunctionWithLengthExpression(t *testing.T) {
-	input := `
-	me: {"pensamiento"};
-	let x = len(thoughts());
-	x
-	`
-
-	evaluated := testEval(input)
-
-	integerLiteral, ok := evaluated.(*object.Integer)
-
-	if !ok {
-		t.Fatalf("object is not Integer. got=%T (%+v)", evaluated, evaluated)
-	}
-
-	if integerLiteral.Value != 1 {
-		t.Fatalf("expected: %d, got=%d", 1, integerLiteral.Value)
-	}
-
-}
-
-func TestTranslationAddition(t *testing.T) {
-	input := `tr: snore {"ronquido"};
-	snore
-	`
-
-	evaluated := testEval(input)
-
-	tr, ok := evaluated.(*object.Translation)
-	if !ok {
-		t.Fatalf("object is not Translation. got=%T (%+v)", evaluated, evaluated)
-	}
-	if tr.Translation != "snore" {
-		t.Fatalf("object is not '%s', got '%s'", "snore", tr.Translation)
-	}
-
-	if tr.Definition != "ronquido" {
-		t.Fatalf("object is not '%s', got '%s'", "ronquido", tr.Definition)
-	}
-}
-
-func TestHashIndexExpressions(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{
-			`{"foo": 5}["foo"]`,
-			5,
-		},
-		{
-			`{"foo": 5}["bar"]`,
-			nil,
-		},
-		{
-			`let key = "foo"; {"foo": 5}[key]`,
-			5,
-		},
-		{
-			`{}["foo"]`,
-			nil,
-		},
-		{
-			`{5: 5}[5]`,
-			5,
-		},
-		{
-			`{true: 5}[true]`,
-			5,
-		},
-		{
-			`{false: 5}[false]`,
-			5,
-		},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		integer, ok := tt.expected.(int)
-		if ok {
-			testIntegerObject(t, evaluated, int64(integer))
-		} else {
-			testNullObject(t, evaluated)
-		}
-	}
-
-}
-
-func TestGrepBuiltinFunction(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected interface{}
-	}{
-		{`
-		word: "abc";
-		word: "abcd";
-		word: "abcde";
-		let c = len(grep("ab"));
-		c;
-		`, 3},
-	}
-
-	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		switch expected := tt.expected.(type) {
-		case int:
-			testIntegerObject(t, evaluated, int64(expected))
-		}
-	}
-
-}
+package evaluator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+)
+
+func TestEvalInteger(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"5", 5},
+		{"10", 10},
+		{"-5", -5},
+		{"-10", -10},
+		{"5 + 5 + 5 + 5 - 10", 10},
+		{"2 * 2 * 2 * 2 * 2", 32},
+		{"-50 + 100 + -50", 0},
+		{"5 * 2 + 10", 20},
+		{"5 + 2 * 10", 25},
+		{"20 + 2 * -10", 0},
+		{"50 / 2 * 2 + 10", 60},
+		{"2 * (5 + 10)", 30},
+		{"3 * 3 * 3 + 10", 37},
+		{"3 * (3 * 3) + 10", 37},
+		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 + 10 % 4 * 3", 8},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+
+}
+
+func testEval(input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	return Eval(program, env)
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%d, want=%d", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+// errorMessage is the expected message of an error result in the tables read
+// by testObject, where plain strings stand for STRING results.
+type errorMessage string
+
+// bigInteger is the expected value of a BIG_INTEGER result, in decimal.
+type bigInteger string
+
+// testObject checks obj against expected: an int, float64, bigInteger, bool,
+// string, nil, errorMessage, an entry or quote compared with object.Equal,
+// []interface{} for an array or map[string]interface{} for a hash with string
+// keys, the last two holding expected values themselves.
+func testObject(t *testing.T, obj object.Object, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case int:
+		return testIntegerObject(t, obj, int64(expected))
+	case float64:
+		return testFloatObject(t, obj, expected)
+	case bigInteger:
+		return testBigIntegerObject(t, obj, string(expected))
+	case bool:
+		return testBooleanObject(t, obj, expected)
+	case string:
+		return testStringObject(t, obj, expected)
+	case nil:
+		if !testNullObject(t, obj) {
+			t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
+			return false
+		}
+		return true
+	case errorMessage:
+		errObj, ok := obj.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", obj, obj)
+			return false
+		}
+		if errObj.Message != string(expected) {
+			t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			return false
+		}
+		return true
+	case []interface{}:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			t.Errorf("object is not Array. got=%T (%+v)", obj, obj)
+			return false
+		}
+		if len(arr.Elements) != len(expected) {
+			t.Errorf("wrong number of elements. got=%d, want=%d", len(arr.Elements), len(expected))
+			return false
+		}
+		for i, el := range expected {
+			if !testObject(t, arr.Elements[i], el) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			t.Errorf("object is not Hash. got=%T (%+v)", obj, obj)
+			return false
+		}
+		if len(hash.Pairs) != len(expected) {
+			t.Errorf("wrong number of pairs. got=%d, want=%d", len(hash.Pairs), len(expected))
+			return false
+		}
+		for key, value := range expected {
+			pair, ok := hash.Pairs[(&object.String{Value: key}).HashKey()]
+			if !ok {
+				t.Errorf("no pair for key %q in %s", key, hash.Inspect())
+				return false
+			}
+			if !testObject(t, pair.Value, value) {
+				return false
+			}
+		}
+		return true
+	case object.Entry, *object.Quote:
+		if !object.Equal(obj, expected.(object.Object)) {
+			t.Errorf("object is not %s. got=%T (%+v)", expected.(object.Object).Inspect(), obj, obj)
+			return false
+		}
+		return true
+	}
+	t.Fatalf("unsupported expected value %T", expected)
+	return false
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%v, want=%v", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testBigIntegerObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.BigInteger)
+	if !ok {
+		t.Errorf("object is not BigInteger. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value.String() != expected {
+		t.Errorf("object has wrong value. got=%s, want=%s", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func testStringObject(t *testing.T, obj object.Object, expected string) bool {
+	result, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("object is not String. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%q, want=%q", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func TestEvalBooleanExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1 < 2", true},
+		{"1 > 2", false},
+		{"1 < 1", false},
+		{"1 > 1", false},
+		{"1 == 1", true},
+		{"1 != 1", false},
+		{"1 == 2", false},
+		{"1 != 2", true},
+		{"true == true", true},
+		{"false == false", true},
+		{"true == false", false},
+		{"true != false", true},
+		{"false != true", true},
+		{"(1 < 2) == true", true},
+		{"(1 < 2) == false", false},
+		{"(1 > 2) == true", false},
+		{"1 <= 1", true},
+		{"2 <= 1", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{`"abc" == "abc"`, true},
+		{`"abc" != "abc"`, false},
+		{`"abc" == "abd"`, false},
+		{`"a" < "b"`, true},
+		{`"b" <= "a"`, false},
+		{`"arenga" > "Arenga"`, true},
+		{`"ñu" >= "nu"`, true},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{`"" && true`, true},
+		{"false && missing", false},
+		{"true || missing", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
+	result, ok := obj.(*object.Boolean)
+	if !ok {
+		t.Errorf("object is not Boolean. got=%T (%+v)", obj, obj)
+		return false
+	}
+
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%t, want=%t", result.Value, expected)
+		return false
+	}
+
+	return true
+}
+
+func TestBangOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!true", false},
+		{"!false", true},
+		{"!5", false},
+		{"!!true", true},
+		{"!!false", false},
+		{"!(true)", false},
+		{"!!5", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+
+}
+
+func TestIfElseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"if (true) { 10 }", 10},
+		{"if (false) { 10 }", nil},
+		{"if (1) { 10 }", 10},
+		{"if (1 < 2) { 10 }", 10},
+		{"if (1 > 2) { 10 }", nil},
+		{"if (1 > 2) { 10 } else { 20 }", 20},
+		{"if (1 < 2) { 10 } else { 20 }", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func testNullObject(t *testing.T, obj object.Object) bool {
+	return obj == NULL
+}
+
+func TestReturnStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"return 10;", 10},
+		{"return 10; 9;", 10},
+		{"return 2 * 5; 9;", 10},
+		{"9; return 2 * 5; 9;", 10},
+		{
+			`
+if (10 > 1) {
+  if (10 > 1) {
+    return 10;
+  }
+
+  return 1;
+}
+`, 10,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"5 + true;",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			"5 + true; 5;",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			"-true",
+			"unknown operator: -BOOLEAN",
+		},
+		{
+			"true + false;",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"5; true + false; 5",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"if (10 > 1) { true + false; }",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			`
+if (10 > 1) {
+  if (10 > 1) {
+    return true + false;
+  }
+
+  return 1;
+}
+`,
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"foobar",
+			"identifier not found: foobar",
+		},
+		{
+			`"Hello" - "World"`,
+			"unknown operator: STRING - STRING",
+		},
+		{
+			`{"name": "Monkey"}[fn(x) { x }];`,
+			"unusable as hash key: FUNCTION",
+		},
+		{
+			"10 / (5 - 5)",
+			"division by zero: 10 / 0",
+		},
+		{
+			"10 % 0",
+			"division by zero: 10 % 0",
+		},
+		{
+			`"a" * "b"`,
+			"unknown operator: STRING * STRING",
+		},
+		{
+			"true && missing",
+			"identifier not found: missing",
+		},
+		{
+			`"a" < 1`,
+			"type mismatch: STRING < INTEGER",
+		},
+		{
+			"let add = fn(x, y) { x + y }; add(1);",
+			"wrong number of arguments. got=1, want=2",
+		},
+		{
+			"fn(x) { x }(1, 2)",
+			"wrong number of arguments. got=2, want=1",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+
+}
+
+func TestLetstatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5 * 5; a;", 25},
+		{"let a = 5; let b = a; b;", 5},
+		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFunctionObject(t *testing.T) {
+	input := "fn(x) { x + 2; };"
+
+	evaluated := testEval(input)
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(fn.Parameters) != 1 {
+		t.Fatalf("function has wrong parameters. Parameters=%+v", fn.Parameters)
+	}
+
+	if fn.Parameters[0].String() != "x" {
+		t.Fatalf("parameters is not 'x'. got=%q", fn.Parameters[0])
+	}
+
+	expectedBody := "(x + 2)"
+
+	if fn.Body.String() != expectedBody {
+		t.Fatalf("body is not %q. got=%q", expectedBody, fn.Body.String())
+	}
+}
+
+func TestFunctionApplication(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let identity = fn(x) { x; }; identity(5);", 5},
+		{"let identity = fn(x) { return x; }; identity(5);", 5},
+		{"let double = fn(x) { x * 2; }; double(5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
+		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
+		{"fn(x) { x; }(5)", 5},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEmptyFunctionBody(t *testing.T) {
+	evaluated := testEval("let noop = fn() {}; let x = noop(); x;")
+	testNullObject(t, evaluated)
+}
+
+func TestClosures(t *testing.T) {
+	input := `
+	let newAdder = fn(x) {
+		fn(y) { x + y };
+	};
+
+	let addTwo = newAdder(2);
+	addTwo(2);
+	`
+	testIntegerObject(t, testEval(input), 4)
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := `"Hello World!"`
+
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not a String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestStringConcatenation(t *testing.T) {
+	input := `"Hello" + " " + "World!"`
+	evaluated := testEval(input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "Hello World!" {
+		t.Errorf("String has wrong value. got=%q", str.Value)
+	}
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`len("")`, 0},
+		{`len("four")`, 4},
+		{`len("hello world")`, 11},
+		{`len("súcubo")`, 6},
+		{`len("Alí Babá")`, 8},
+		{`max(3, 5, 2)`, 5},
+		{`max(2)`, 2},
+		{`exists("alv")`, false},
+		{`
+		word: "cultual" {"Perteneciente o relativo al culto religioso."};
+		defined("cultual");
+		`, true},
+		{`len(1)`, "argument to `len` not supported, got INTEGER"},
+		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		{`push([])`, "wrong number of arguments. got=1, want=2"},
+		{`push(1, 2)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`first()`, "wrong number of arguments. got=0, want=1"},
+		{`last()`, "wrong number of arguments. got=0, want=1"},
+		{`rest()`, "wrong number of arguments. got=0, want=1"},
+		{`exists()`, "wrong number of arguments. got=0, want=1"},
+		{`defined()`, "wrong number of arguments. got=0, want=1"},
+		{`max()`, "wrong number of arguments. got=0, want=1"},
+		{`len({
+			"one": 10,
+			"two": 1
+		})`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		case bool:
+			ok, _ := tt.expected.(bool)
+			fmt.Println(ok)
+			testBooleanObject(t, evaluated, ok)
+		}
+	}
+}
+
+func TestArrayLiterals(t *testing.T) {
+	input := "[1, 2 * 2, 3 + 3]"
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Array)
+	if !ok {
+		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if len(result.Elements) != 3 {
+		t.Fatalf("array has wrong num of elements. got=%d",
+			len(result.Elements))
+	}
+
+	testIntegerObject(t, result.Elements[0], 1)
+	testIntegerObject(t, result.Elements[1], 4)
+	testIntegerObject(t, result.Elements[2], 6)
+}
+
+func TestArrayIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			"[1, 2, 3][0]",
+			1,
+		},
+		{
+			"[1, 2, 3][1]",
+			2,
+		},
+		{
+			"[1, 2, 3][2]",
+			3,
+		},
+		{
+			"let i = 0; [1][i];",
+			1,
+		},
+		{
+			"[1, 2, 3][1 + 1];",
+			3,
+		},
+		{
+			"let myArray = [1, 2, 3]; myArray[2];",
+			3,
+		},
+		{
+			"let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];",
+			6,
+		},
+		{
+			"let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]",
+			2,
+		},
+		{
+			"[1, 2, 3][3]",
+			nil,
+		},
+		{
+			"[1, 2, 3][-1]",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestHashLiterals(t *testing.T) {
+	input := `let two = "two";
+    {
+        "one": 10 - 9,
+        two: 1 + 1,
+        "thr" + "ee": 6 / 2,
+        4: 4,
+        true: 5,
+        false: 6
+	}`
+
+	evaluated := testEval(input)
+	result, ok := evaluated.(*object.Hash)
+	if !ok {
+		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := map[object.HashKey]int64{
+		(&object.String{Value: "one"}).HashKey():   1,
+		(&object.String{Value: "two"}).HashKey():   2,
+		(&object.String{Value: "three"}).HashKey(): 3,
+		(&object.Integer{Value: 4}).HashKey():      4,
+		TRUE.HashKey():                             5,
+		FALSE.HashKey():                            6,
+	}
+
+	if len(result.Pairs) != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	}
+
+	for expectedKey, expectedValue := range expected {
+		pair, ok := result.Pairs[expectedKey]
+		if !ok {
+			t.Errorf("no pair for given key in Pairs")
+		}
+
+		testIntegerObject(t, pair.Value, expectedValue)
+	}
+}
+
+func TestWordAddition(t *testing.T) {
+	input := `word: "bulo" {"burla"};
+	bulo;
+	`
+
+	evaluated := testEval(input)
+
+	w, ok := evaluated.(*object.Word)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if w.Word != "bulo" {
+		t.Fatalf("object is not '%s', got '%s'", "bulo", w.Word)
+	}
+
+	if w.Definition != "burla" {
+		t.Fatalf("object is not '%s', got '%s'", "burla", w.Word)
+	}
+}
+
+func TestQuoteAddition(t *testing.T) {
+	input := `quote: "Byung-Chul Han" {"Some text ... "};`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	Eval(program, env)
+
+	if len(env.Quotes()) < 1 {
+		t.Fatal("quotes are empty ... ")
+	}
+
+	quote := env.Quotes()[0]
+	if quote.By != "Byung-Chul Han" {
+		t.Errorf("quote author wrong, got='%s', expected: '%s'", quote.By, "Byung-Chul Han")
+	}
+
+	if quote.Text != "Some text ... " {
+		t.Errorf("quote text wrong, got='%s', expected: '%s'", quote.Text, "Some text ... ")
+	}
+}
+
+func TestRefAddition(t *testing.T) {
+	input := `ref: "a" {"b"};
+	a;
+	`
+
+	evaluated := testEval(input)
+
+	ref, ok := evaluated.(*object.Reference)
+	if !ok {
+		t.Fatalf("object is not Reference. got=%T (%+v)", evaluated, evaluated)
+	}
+	if ref.Ref != "a" {
+		t.Fatalf("object is not '%s', got '%s'", "a", ref.Ref)
+	}
+
+	if ref.Definition != "b" {
+		t.Fatalf("object is not '%s', got '%s'", "b", ref.Definition)
+	}
+}
+
+func TestConceptAddition(t *testing.T) {
+	input := `cpt: "a" {"b"};
+	a
+	`
+
+	evaluated := testEval(input)
+
+	cpt, ok := evaluated.(*object.Concept)
+	if !ok {
+		t.Fatalf("object is not Concept. got=%T (%+v)", evaluated, evaluated)
+	}
+	if cpt.Concept != "a" {
+		t.Fatalf("object is not '%s', got '%s'", "a", cpt.Concept)
+	}
+
+	if cpt.Definition != "b" {
+		t.Fatalf("object is not '%s', got '%s'", "b", cpt.Concept)
+	}
+}
+
+func TestMeThoughtAddition(t *testing.T) {
+	input := `me: {"pensamiento"};`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	Eval(program, env)
+
+	if len(env.Thoughts()) < 1 {
+		t.Fatal("thoughts are empty ... ")
+	}
+
+	thought := env.Thoughts()[0]
+	if thought != "pensamiento" {
+		t.Errorf("thought wrong, got='%s', expected: '%s'", thought, "pensamiento")
+	}
+
+}
+
+func TestThoughtsFunctionWithExpression(t *testing.T) {
+	input := `
+	me: {"pensamiento"};
+	let x = thoughts()[0];
+	x
+	`
+
+	evaluated := testEval(input)
+
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if str.Value != "pensamiento" {
+		t.Fatalf("object is not '%s', got '%s'", "pensamiento", str.Value)
+	}
+
+}
+
+func TestThoughtsFunctionWithLengthExpression(t *testing.T) {
+	input := `
+	me: {"pensamiento"};
+	let x = len(thoughts());
+	x
+	`
+
+	evaluated := testEval(input)
+
+	integerLiteral, ok := evaluated.(*object.Integer)
+
+	if !ok {
+		t.Fatalf("object is not Integer. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if integerLiteral.Value != 1 {
+		t.Fatalf("expected: %d, got=%d", 1, integerLiteral.Value)
+	}
+
+}
+
+func TestTranslationAddition(t *testing.T) {
+	input := `tr: snore {"ronquido"};
+	snore
+	`
+
+	evaluated := testEval(input)
+
+	tr, ok := evaluated.(*object.Translation)
+	if !ok {
+		t.Fatalf("object is not Translation. got=%T (%+v)", evaluated, evaluated)
+	}
+	if tr.Translation != "snore" {
+		t.Fatalf("object is not '%s', got '%s'", "snore", tr.Translation)
+	}
+
+	if tr.Definition != "ronquido" {
+		t.Fatalf("object is not '%s', got '%s'", "ronquido", tr.Definition)
+	}
+}
+
+func TestHashIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{
+			`{"foo": 5}["foo"]`,
+			5,
+		},
+		{
+			`{"foo": 5}["bar"]`,
+			nil,
+		},
+		{
+			`let key = "foo"; {"foo": 5}[key]`,
+			5,
+		},
+		{
+			`{}["foo"]`,
+			nil,
+		},
+		{
+			`{5: 5}[5]`,
+			5,
+		},
+		{
+			`{true: 5}[true]`,
+			5,
+		},
+		{
+			`{false: 5}[false]`,
+			5,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+
+}
+
+func TestFieldExpressions(t *testing.T) {
+	input := `
+# Ostentación.
+word: "boato" [lang: "es"] {"Ostentación, pompa."};
+ref: "Musil";
+cpt: "Zeitgeist";
+tr: Weltanschauung {"cosmovisión"};
+let h = {"key": 1, "nested": {"deep": "yes"}};
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`boato.name`, "boato"},
+		{`boato.definition`, "Ostentación, pompa."},
+		{`boato.kind`, "word"},
+		{`boato.doc`, "Ostentación."},
+		{`boato.attributes.lang`, "es"},
+		{`Musil.kind`, "ref"},
+		{`Musil.definition`, ""},
+		{`Zeitgeist.kind`, "cpt"},
+		{`Weltanschauung.definition`, "cosmovisión"},
+		{`h.key`, 1},
+		{`h.nested.deep`, "yes"},
+		{`h.missing`, nil},
+		{`boato.size`, errorMessage("unknown field size of WORD")},
+		{`let n = 1; n.value`, errorMessage("field access not supported: INTEGER")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.input)
+		if evaluated == nil {
+			t.Errorf("%s: no result", tt.input)
+			continue
+		}
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestQuoteFields(t *testing.T) {
+	q := &object.Quote{By: "Byung-Chul Han", Text: "Some text"}
+
+	tests := []struct {
+		field    string
+		expected interface{}
+	}{
+		{"by", "Byung-Chul Han"},
+		{"text", "Some text"},
+		{"name", errorMessage("unknown field name of QUOTE")},
+	}
+
+	for _, tt := range tests {
+		evaluated := evalFieldExpression(q, tt.field)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for q.%s", tt.field)
+		}
+	}
+}
+
+func TestGrepBuiltinFunction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		word: "abc";
+		word: "abcd";
+		word: "abcde";
+		let c = len(grep("ab"));
+		c;
+		`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		}
+	}
+
+}
+
+func TestKnowledgeBaseIsProgramWide(t *testing.T) {
+	input := `
+	let define = fn() {
+		word: "boato" {"ostentación"};
+		ref: "Musil";
+	};
+	define();
+	if (true) {
+		cpt: "Jena";
+	}
+	let x = 5;
+	`
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	env := object.NewEnvironment()
+	Eval(program, env)
+
+	kb := env.Knowledge()
+	for _, name := range []string{"boato", "Musil", "Jena"} {
+		if _, ok := kb.Lookup(name); !ok {
+			t.Errorf("entry %q not recorded in the knowledge base", name)
+		}
+	}
+
+	if _, ok := kb.Lookup("x"); ok {
+		t.Errorf("let binding leaked into the knowledge base")
+	}
+
+	if _, ok := env.Get("boato"); ok {
+		t.Errorf("entry leaked into the variable scope")
+	}
+
+	if kb.Len() != 3 {
+		t.Errorf("wrong number of entries. got=%d, want=3", kb.Len())
+	}
+}
+
+func TestCountBuiltinsOnlySeeVocabulary(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`let a = 1; word: "a1"; word: "a2"; wordcount();`, 2},
+		{`let f = fn() { word: "inner"; }; f(); wordcount();`, 1},
+		{`let a = 1; ref: "r"; wc();`, 1},
+		{`me: {"uno"}; me: {"dos"}; mecount();`, 2},
+		{`word: "abc"; let abcd = 1; len(grep("ab"));`, 1},
+		{`let f = fn() { quote: "Han" {"text"}; }; f(); counts()["quotes"];`, 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestGrepBuiltinOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`word: "tabc"; ref: "abc"; word: "Zabc"; grep("abc");`, []interface{}{"tabc", "abc", "Zabc"}},
+		{`word: "tabc"; ref: "abc"; word: "Zabc"; grep("abc", "definition");`, []interface{}{"tabc", "abc", "Zabc"}},
+		{`word: "tabc"; ref: "abc"; word: "Zabc"; grep("abc", "alpha");`, []interface{}{"abc", "tabc", "Zabc"}},
+		{`word: "tabc"; ref: "abc"; word: "Zabc"; grep("abc", "kind");`, []interface{}{"tabc", "Zabc", "abc"}},
+		{`grep("abc", "random");`, errorMessage("unknown order for `grep`: \"random\", want \"definition\", \"alpha\" or \"kind\"")},
+		{`word: "a"; ref: "b"; counts();`, map[string]interface{}{"cpts": 0, "quotes": 0, "refs": 1, "thoughts": 0, "trs": 0, "words": 1}},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestEntryCollectionBuiltins(t *testing.T) {
+	input := `
+word: "vodevil" [tags: ["2666", "teatro"]];
+word: "boato" [tags: ["latin", "2666"]] {"Ostentación."};
+ref: "Musil" [tags: "2666"] {"Robert Musil"};
+cpt: "Zeitgeist";
+tr: Weltanschauung {"cosmovisión"};
+word: "Arenga" {"Discurso."};
+quote: "Byung-Chul Han" {"Some text"};
+`
+
+	vodevil := &object.Word{Word: "vodevil"}
+	boato := &object.Word{Word: "boato", Definition: "Ostentación."}
+	arenga := &object.Word{Word: "Arenga", Definition: "Discurso."}
+	musil := &object.Reference{Ref: "Musil", Definition: "Robert Musil"}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`words()`, []interface{}{vodevil, boato, arenga}},
+		{`refs()`, []interface{}{musil}},
+		{`concepts()`, []interface{}{&object.Concept{Concept: "Zeitgeist"}}},
+		{`translations()`, []interface{}{&object.Translation{Translation: "Weltanschauung", Definition: "cosmovisión"}}},
+		{`words({"defined": true})`, []interface{}{boato, arenga}},
+		{`words({"defined": false})`, []interface{}{vodevil}},
+		{`words({"tag": "2666"})`, []interface{}{vodevil, boato}},
+		{`words({"tag": "2666", "defined": true})`, []interface{}{boato}},
+		{`refs({"tag": "2666"})`, []interface{}{musil}},
+		{`words({"order": "alpha"})`, []interface{}{arenga, boato, vodevil}},
+		{`words()[1].definition`, "Ostentación."},
+		{`quotes()`, []interface{}{&object.Quote{By: "Byung-Chul Han", Text: "Some text"}}},
+		{`quotes()[0].by`, "Byung-Chul Han"},
+		{`words(1)`, errorMessage("argument to `words` must be HASH, got INTEGER")},
+		{`refs("2666")`, errorMessage("argument to `refs` must be HASH, got STRING")},
+		{`words({"tags": "2666"})`, errorMessage("unknown filter for `words`: \"tags\", want \"defined\", \"tag\" or \"order\"")},
+		{`refs({"defined": 1})`, errorMessage("filter \"defined\" of `refs` must be BOOLEAN, got INTEGER")},
+		{`concepts({"order": "random"})`, errorMessage("unknown order for `concepts`: \"random\", want \"definition\", \"alpha\" or \"kind\"")},
+		{`translations({}, {})`, errorMessage("wrong number of arguments. got=2, want=0 or 1")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	// FLOATs inspect as literals, which spell out large values.
+	e300 := "1" + strings.Repeat("0", 300) + ".0"
+	e400 := "1" + strings.Repeat("0", 400)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1.5 + 1", 2.5},
+		{"1 + 1.5", 2.5},
+		{"3.0 * 2", 6.0},
+		{"7 / 2", 3},
+		{"7 / 2.0", 3.5},
+		{"7.5 % 2", 1.5},
+		{"-2.5", -2.5},
+		{"1 < 1.5", true},
+		{"2.0 == 2", true},
+		{"2.5 >= 3", false},
+		{"[1, 2.0] == [1.0, 2]", true},
+		{"{1: 2}[1.0]", 2},
+
+		{"9223372036854775807 + 1", bigInteger("9223372036854775808")},
+		{"-9223372036854775807 - 2", bigInteger("-9223372036854775809")},
+		{"4294967296 * 4294967296", bigInteger("18446744073709551616")},
+		{"-9223372036854775807 - 1", -9223372036854775807 - 1},
+		{"-(-9223372036854775807 - 1)", bigInteger("9223372036854775808")},
+		{"(-9223372036854775807 - 1) / -1", bigInteger("9223372036854775808")},
+		{"100000000000000000000 - 99999999999999999999", 1},
+		{"100000000000000000000 % 7", 2},
+		{"100000000000000000000 / 10 > 9223372036854775807", true},
+		{"100000000000000000000 == 100000000000000000000", true},
+		{"100000000000000000000 + 0.5", 1e20},
+		{"9007199254740993 == 9007199254740992.0", false},
+		{"9007199254740993 > 9007199254740992.0", true},
+		{"100000000000000000000 == 100000000000000000000.0", true},
+		{`{9007199254740993: "x"}[9007199254740992.0]`, nil},
+		{`{100000000000000000000: "x"}[100000000000000000000.0]`, "x"},
+		{"len(uniq([9007199254740993, 9007199254740992.0]))", 2},
+		{`let ks = []; for (k in {2.5: 0, 10: 0, 1.5: 0, 100000000000000000000: 0, 20000000000000000000: 0}) { let ks = push(ks, k) }; ks`,
+			[]interface{}{1.5, 2.5, 10, bigInteger("20000000000000000000"), bigInteger("100000000000000000000")}},
+
+		{"int(3.9)", 3},
+		{"int(-3.9)", -3},
+		{`int("42")`, 42},
+		{`int("123456789012345678901234567890")`, bigInteger("123456789012345678901234567890")},
+		{"float(2)", 2.0},
+		{`float("0.25")`, 0.25},
+		{"str(1.5)", "1.5"},
+		{"str(42) + str(true)", "42true"},
+		{`str("a")`, "a"},
+		{`len(filter([1, 2, 3], fn(x) { x > 1 })) * 100 / float(3)`, 66.66666666666667},
+
+		{"1.5 / 0", errorMessage("division by zero: 1.5 / 0")},
+		{"100000000000000000000 % 0", errorMessage("division by zero: 100000000000000000000 % 0")},
+		{`int("4x")`, errorMessage("cannot convert \"4x\" to INTEGER")},
+		{`float("x")`, errorMessage("cannot convert \"x\" to FLOAT")},
+		{`float("inf")`, errorMessage("cannot convert \"inf\" to FLOAT")},
+		{`float("NaN")`, errorMessage("cannot convert \"NaN\" to FLOAT")},
+		{`float("1e400")`, errorMessage("cannot convert \"1e400\" to FLOAT")},
+		{`float("1e300") * float("1e300")`, errorMessage("float overflow: " + e300 + " * " + e300)},
+		{"float(" + e400 + ")", errorMessage("float overflow: float(" + e400 + ")")},
+		{e400 + " + 0.5", errorMessage("float overflow: " + e400 + " + 0.5")},
+		{"int([])", errorMessage("argument to `int` not supported, got ARRAY")},
+		{`1.5 + "a"`, errorMessage("type mismatch: FLOAT + STRING")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	input := `
+word: "boato" {"Ostentación."};
+ref: "Musil";
+quote: "Han" {"Some text"};
+quote: "Han" {"Some text"};
+quote: "Bolaño";
+let f = fn(x) { x };
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1] == [1]`, true},
+		{`[1, [2, "a"]] == [1, [2, "a"]]`, true},
+		{`[1, 2] != [1]`, true},
+		{`{"a": [1]} == {"a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`[] == {}`, false},
+		{`boato == words()[0]`, true},
+		{`boato == Musil`, false},
+		{`quotes()[0] == quotes()[1]`, true},
+		{`quotes()[0] == quotes()[2]`, false},
+		{`f == f`, true},
+		{`f == fn(x) { x }`, false},
+		{`{boato: 1, Musil: 2}[boato]`, 1},
+		{`{boato: 1}[words()[0]]`, 1},
+		{`len(uniq([boato, Musil, boato, words()[0]]))`, 2},
+		{`len(group_by(refs(), fn(r) { r })[Musil])`, 1},
+		{`group_by(refs(), fn(r) { r })[Musil][0] == Musil`, true},
+		{`{quotes()[0]: 1}[quotes()[1]]`, 1},
+		{`len(uniq(quotes()))`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let s = []; for (x in [1, 2, 3]) { let s = push(s, x * 2); }; s`, []interface{}{2, 4, 6}},
+		{`let s = []; for (i, x in ["a", "b"]) { let s = push(s, i); }; s`, []interface{}{0, 1}},
+		{`let s = []; for (k in {"b": 1, "a": 2}) { let s = push(s, k); }; s`, []interface{}{"a", "b"}},
+		{`let s = []; for (k, v in {3: "c", 1: "a", 2: "b"}) { let s = push(s, v); }; s`, []interface{}{"a", "b", "c"}},
+		{`let s = []; for (k in {"1": 0, 2: 0, true: 0}) { let s = push(s, k); }; s`, []interface{}{true, 2, "1"}},
+		{`let s = []; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } let s = push(s, x); }; s`, []interface{}{1, 2}},
+		{`let s = []; for (x in [1, 2, 3, 4]) { if (x == 2) { continue; } let s = push(s, x); }; s`, []interface{}{1, 3, 4}},
+		{`let n = 0; for (x in []) { let n = 1; }; n`, 0},
+		{`let i = 0; while (i < 5) { let i = i + 1; }; i`, 5},
+		{`let i = 0; while (true) { let i = i + 1; if (i > 3) { break; } }; i`, 4},
+		{`let f = fn() { for (x in [1, 2, 3]) { if (x > 1) { return x; } } }; f()`, 2},
+		{`let n = 0; for (x in [[1, 2], [3]]) { for (y in x) { if (y == 2) { break; } let n = n + y; } }; n`, 4},
+		{`word: "a"; word: "b"; let s = []; for (w in words()) { let s = push(s, w.name); }; s`, []interface{}{"a", "b"}},
+		{`for (x in 5) { }`, errorMessage("cannot iterate over INTEGER")},
+		{`for (x in [1]) { missing }`, errorMessage("identifier not found: missing")},
+		{`while (missing) { }`, errorMessage("identifier not found: missing")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("%s: no result", tt.input)
+			continue
+		}
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestLoopVariableScope(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// The loop variable does not leak, nor clobber an outer one.
+		{`for (x in [1, 2]) { }; x`, errorMessage("identifier not found: x")},
+		{`let x = "outer"; for (x in [1, 2]) { }; x`, "outer"},
+		{`let x = "outer"; for (x in [1, 2]) { let x = 10; }; x`, "outer"},
+		// Each iteration has its own variable, so closures keep theirs.
+		{`let fs = []; for (x in [1, 2, 3]) { let fs = push(fs, fn() { x }); }; fs[0]() + fs[2]()`, 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	input := `let x = 1;
+if (true) {
+	let y = z;
+}`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Pos.String() != "3:10" {
+		t.Errorf("wrong error position. expected=%s, got=%s", "3:10", errObj.Pos)
+	}
+}
+
+func TestErrorStack(t *testing.T) {
+	input := `let lookup = fn(name) {
+	missing + name;
+};
+let report = fn() {
+	lookup("boato");
+};
+fn() { report() }();`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "identifier not found: missing" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+
+	if errObj.Pos.String() != "2:2" {
+		t.Errorf("wrong error position. expected=%s, got=%s", "2:2", errObj.Pos)
+	}
+
+	expected := []string{"lookup (5:2)", "report (7:8)", "fn (7:1)"}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack length. expected=%d, got=%d", len(expected), len(errObj.Stack))
+	}
+
+	for i, frame := range expected {
+		if errObj.Stack[i].String() != frame {
+			t.Errorf("stack[%d] wrong. expected=%q, got=%q", i, frame, errObj.Stack[i].String())
+		}
+	}
+}
+
+func TestSaveBuiltin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saved.wb")
+	input := fmt.Sprintf(`word: "boato" {"Ostentación."};
+save(%q);`, path)
+
+	testNullObject(t, testEval(input))
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != "word: \"boato\" {\"Ostentación.\"};\n" {
+		t.Errorf("wrong saved source. got=%q", saved)
+	}
+
+	evaluated := testEval(`save(1)`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "argument to `save` must be STRING, got INTEGER" {
+		t.Errorf("wrong result for save(1). got=%s", evaluated.Inspect())
+	}
+}
+
+func TestDocBuiltin(t *testing.T) {
+	input := `
+# Ostentación, pompa.
+# Del lat. boatus.
+word: "boato" {"Ostentación."};
+
+# Not a doc comment: a blank line follows.
+
+ref: "Musil";
+tr: Zeitgeist; # trailing, not a doc comment
+`
+
+	tests := []struct {
+		call     string
+		expected interface{}
+	}{
+		{`doc("boato")`, "Ostentación, pompa.\nDel lat. boatus."},
+		{`doc("Musil")`, ""},
+		{`doc("Zeitgeist")`, ""},
+		{`doc("nadie")`, nil},
+		{`doc(1)`, "argument to `doc` must be STRING or an entry, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.call)
+		switch expected := tt.expected.(type) {
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("%s: wrong error message. expected=%q, got=%q", tt.call, expected, errObj.Message)
+				}
+				continue
+			}
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("%s: expected %q, got=%s", tt.call, expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestEntryAttributes(t *testing.T) {
+	input := `
+word: "boato" [tags: ["latin", "2666"], lang: "es", found: 1 + 2] {"Ostentación."};
+ref: "Musil";
+quote: "Byung-Chul Han" [lang: "ko", year: 2010] {"Some text"};
+quote: "Musil" {"Other text"};
+`
+
+	tests := []struct {
+		call     string
+		expected interface{}
+	}{
+		{`attrs("boato")`, map[string]interface{}{"found": 3, "lang": "es", "tags": []interface{}{"latin", "2666"}}},
+		{`attrs("boato")["tags"][1]`, "2666"},
+		{`attrs("Musil")`, map[string]interface{}{}},
+		{`attrs(boato)["lang"]`, "es"},
+		{`attrs("nadie")`, nil},
+		{`word: "x" [n: missing];`, errorMessage("identifier not found: missing")},
+		{`quotes()[0].attributes`, map[string]interface{}{"lang": "ko", "year": 2010}},
+		{`quotes()[0].attributes.lang`, "ko"},
+		{`quotes()[1].attributes`, map[string]interface{}{}},
+		{`quote: "x" [n: missing];`, errorMessage("identifier not found: missing")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.call)
+		if evaluated == nil {
+			t.Errorf("%s: no result", tt.call)
+			continue
+		}
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.call)
+		}
+	}
+}
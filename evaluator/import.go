@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"wordbuilder/ast"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+)
+
+// SourceExt is the extension of the files loaded by a directory import.
+const SourceExt = ".wb"
+
+// EvalFile evaluates program, parsed from the file at path, in env. The file
+// is recorded in the knowledge base, so importing it again has no effect and
+// importing it from one of its own imports is reported as a cycle. A file
+// whose evaluation fails is forgotten, so it can be imported again once
+// fixed.
+func EvalFile(path string, program *ast.Program, env *object.Environment) object.Object {
+	key := fileKey(path)
+	kb := env.Knowledge()
+
+	kb.StartFile(key)
+	result := Eval(program, env)
+	if isError(result) {
+		kb.AbortFile(key)
+	} else {
+		kb.FinishFile(key)
+	}
+
+	return result
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	path := node.Path.Value
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(node.Pos().File), path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return newError("cannot import %q: %s", node.Path.Value, errorText(err))
+	}
+
+	if !info.IsDir() {
+		return importFile(path, env)
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return newError("cannot import %q: %s", node.Path.Value, errorText(err))
+	}
+
+	names := []string{}
+	for _, f := range files {
+		if !f.IsDir() && filepath.Ext(f.Name()) == SourceExt {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := filepath.Join(path, name)
+		// The directory may hold the file importing it, or one of the
+		// files importing that; they are already being loaded.
+		if started, done := env.Knowledge().FileState(fileKey(file)); started && !done {
+			continue
+		}
+		if result := importFile(file, env); isError(result) {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// importFile parses the file at path and evaluates it in a new top-level
+// environment sharing the knowledge base of env. Only its entries, thoughts and
+// quotes are visible to the importing file, not its `let` bindings.
+func importFile(path string, env *object.Environment) object.Object {
+	kb := env.Knowledge()
+
+	started, done := kb.FileState(fileKey(path))
+	if done {
+		return nil
+	}
+	if started {
+		return newError("import cycle: %s", importChain(kb, fileKey(path)))
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return newError("cannot import %q: %s", path, errorText(err))
+	}
+
+	source := string(content)
+	p := parser.New(lexer.NewFile(path, source))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		msgs := []string{}
+		for _, e := range p.Errors() {
+			msgs = append(msgs, e.Render(source))
+		}
+		return newError("parse errors in %s:\n%s", path, strings.Join(msgs, "\n"))
+	}
+
+	result := EvalFile(path, program, object.NewSharedEnvironment(kb))
+	if isError(result) {
+		return result
+	}
+
+	return nil
+}
+
+// importChain describes the files being loaded from the first import of key
+// back to key itself.
+func importChain(kb *object.KnowledgeBase, key string) string {
+	loading := kb.Loading()
+	for i, file := range loading {
+		if file == key {
+			loading = loading[i:]
+			break
+		}
+	}
+
+	names := []string{}
+	for _, file := range append(loading, key) {
+		names = append(names, displayPath(file))
+	}
+	return strings.Join(names, " -> ")
+}
+
+// fileKey identifies a source file however its path was spelled.
+func fileKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// displayPath shortens a file key relative to the working directory.
+func displayPath(key string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return key
+	}
+	if rel, err := filepath.Rel(wd, key); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return key
+}
+
+func errorText(err error) string {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,165 @@
+package evaluator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testEvalFile(t *testing.T, path string) (object.Object, *object.Environment) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := parser.New(lexer.NewFile(path, string(content)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has %d errors", len(p.Errors()))
+	}
+
+	env := object.NewEnvironment()
+	return EvalFile(path, program, env), env
+}
+
+func TestImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.wb": `import "vocab";
+import "vocab/b.wb";
+let local = 1;
+word: "main" {"m"};`,
+		"vocab/a.wb": `word: "alfa" {"a"};
+me: {"a thought"};`,
+		"vocab/b.wb": `import "a.wb";
+let local = 2;
+word: "beta" {"b"};`,
+		"vocab/notes.txt": `not wordbuilder source`,
+	})
+
+	evaluated, env := testEvalFile(t, filepath.Join(dir, "main.wb"))
+	if isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.Inspect())
+	}
+
+	names := []string{}
+	for _, entry := range env.Knowledge().Entries(object.DefinitionOrder) {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != "alfa beta main" {
+		t.Errorf("wrong entries. got=%v", names)
+	}
+
+	if len(env.Thoughts()) != 1 {
+		t.Errorf("imported file loaded more than once. thoughts=%v", env.Thoughts())
+	}
+
+	if len(env.Knowledge().Files()) != 3 {
+		t.Errorf("wrong number of files loaded. got=%v", env.Knowledge().Files())
+	}
+
+	local, _ := env.Get("local")
+	testIntegerObject(t, local, 1)
+}
+
+func TestImportOwnDirectory(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.wb":  `import "."; word: "main" {"m"};`,
+		"other.wb": `word: "otro" {"o"};`,
+	})
+
+	evaluated, env := testEvalFile(t, filepath.Join(dir, "main.wb"))
+	if isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.Inspect())
+	}
+
+	names := []string{}
+	for _, entry := range env.Knowledge().Entries(object.DefinitionOrder) {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != "otro main" {
+		t.Errorf("wrong entries. got=%v", names)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"cycle.wb":   `import "cycle2.wb";`,
+		"cycle2.wb":  `import "cycle.wb";`,
+		"missing.wb": `import "nope.wb";`,
+		"parse.wb":   `import "broken.wb";`,
+		"broken.wb":  `word: boato;`,
+	})
+
+	tests := []struct {
+		file            string
+		expectedMessage string
+	}{
+		{"cycle.wb", "import cycle: "},
+		{"missing.wb", `cannot import "nope.wb": no such file or directory`},
+		{"parse.wb", "parse errors in " + filepath.Join(dir, "broken.wb") + ":\n" +
+			filepath.Join(dir, "broken.wb") + ":1:7: expected name after `word:`"},
+	}
+
+	for _, tt := range tests {
+		evaluated, _ := testEvalFile(t, filepath.Join(dir, tt.file))
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: no error object returned. got=%T(%+v)", tt.file, evaluated, evaluated)
+			continue
+		}
+
+		if !strings.HasPrefix(errObj.Message, tt.expectedMessage) {
+			t.Errorf("%s: wrong error message. expected prefix=%q, got=%q",
+				tt.file, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestImportAfterFailure(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"vocab.wb": `word: "alfa" {"a"}; missing;`,
+	})
+
+	env := object.NewEnvironment()
+	program := parser.New(lexer.NewFile(filepath.Join(dir, "main.wb"), `import "vocab.wb";`)).ParseProgram()
+
+	evaluated := Eval(program, env)
+	if !isError(evaluated) {
+		t.Fatalf("expected an error, got=%v", evaluated)
+	}
+	if len(env.Knowledge().Loading()) != 0 || len(env.Knowledge().Files()) != 0 {
+		t.Errorf("failed import still recorded. loading=%v, files=%v",
+			env.Knowledge().Loading(), env.Knowledge().Files())
+	}
+
+	fixed := `word: "alfa" {"a"}; word: "beta" {"b"};`
+	if err := ioutil.WriteFile(filepath.Join(dir, "vocab.wb"), []byte(fixed), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if evaluated := Eval(program, env); isError(evaluated) {
+		t.Fatalf("unexpected error: %s", evaluated.Inspect())
+	}
+	if _, ok := env.Knowledge().Lookup("beta"); !ok {
+		t.Errorf("fixed file was not imported again")
+	}
+}
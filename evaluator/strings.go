@@ -0,0 +1,224 @@
+package evaluator
+
+import (
+	"strings"
+	"unicode"
+	"wordbuilder/object"
+)
+
+// The string builtins count and cut strings in characters, not bytes, so
+// that they work on accented text.
+func init() {
+	for name, fn := range map[string]object.BuiltinFunction{
+		"upper":       stringFunction("upper", strings.ToUpper),
+		"lower":       stringFunction("lower", strings.ToLower),
+		"trim":        stringFunction("trim", strings.TrimSpace),
+		"split":       splitBuiltin,
+		"join":        joinBuiltin,
+		"replace":     replaceBuiltin,
+		"contains":    stringPredicate("contains", strings.Contains),
+		"starts_with": stringPredicate("starts_with", strings.HasPrefix),
+		"ends_with":   stringPredicate("ends_with", strings.HasSuffix),
+		"index":       indexBuiltin,
+		"substr":      substrBuiltin,
+		"lines":       linesBuiltin,
+		"split_words": splitWordsBuiltin,
+		"reverse":     reverseBuiltin,
+	} {
+		builtins[name] = &object.Builtin{Fn: fn}
+	}
+}
+
+// stringArgs checks that the builtin name got n STRING arguments and returns
+// their values.
+func stringArgs(name string, args []object.Object, n int) ([]string, *object.Error) {
+	if len(args) != n {
+		return nil, newError("wrong number of arguments. got=%d, want=%d", len(args), n)
+	}
+
+	values := make([]string, n)
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, newError("argument to `%s` must be STRING, got %s", name, arg.Type())
+		}
+		values[i] = str.Value
+	}
+	return values, nil
+}
+
+func stringFunction(name string, fn func(string) string) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		values, err := stringArgs(name, args, 1)
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: fn(values[0])}
+	}
+}
+
+func stringPredicate(name string, fn func(s, sub string) bool) object.BuiltinFunction {
+	return func(env *object.Environment, args ...object.Object) object.Object {
+		values, err := stringArgs(name, args, 2)
+		if err != nil {
+			return err
+		}
+		return nativeBoolToBooleanIObject(fn(values[0], values[1]))
+	}
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		elements[i] = &object.String{Value: v}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// split(s, sep) cuts s around each sep, or into characters if sep is "".
+func splitBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	values, err := stringArgs("split", args, 2)
+	if err != nil {
+		return err
+	}
+	return stringArray(strings.Split(values[0], values[1]))
+}
+
+// join(array, sep) puts sep between the strings of array.
+func joinBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `join` must be ARRAY, got %s", args[0].Type())
+	}
+	sep, ok := args[1].(*object.String)
+	if !ok {
+		return newError("separator of `join` must be STRING, got %s", args[1].Type())
+	}
+
+	values := make([]string, len(arr.Elements))
+	for i, elem := range arr.Elements {
+		str, ok := elem.(*object.String)
+		if !ok {
+			return newError("elements of `join` must be STRING, got %s", elem.Type())
+		}
+		values[i] = str.Value
+	}
+	return &object.String{Value: strings.Join(values, sep.Value)}
+}
+
+// replace(s, old, new) replaces every old in s by new.
+func replaceBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	values, err := stringArgs("replace", args, 3)
+	if err != nil {
+		return err
+	}
+	return &object.String{Value: strings.Replace(values[0], values[1], values[2], -1)}
+}
+
+// index(s, sub) returns the position of the first sub in s, in characters,
+// or -1.
+func indexBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	values, err := stringArgs("index", args, 2)
+	if err != nil {
+		return err
+	}
+
+	i := strings.Index(values[0], values[1])
+	if i < 0 {
+		return &object.Integer{Value: -1}
+	}
+	return &object.Integer{Value: int64(len([]rune(values[0][:i])))}
+}
+
+// substr(s, start[, length]) returns the characters of s from start on, at
+// most length of them.
+func substrBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `substr` must be STRING, got %s", args[0].Type())
+	}
+	bounds := make([]int64, len(args)-1)
+	for i, arg := range args[1:] {
+		n, ok := arg.(*object.Integer)
+		if !ok || n.Value < 0 {
+			return newError("start and length of `substr` must be non-negative INTEGER, got %s", arg.Inspect())
+		}
+		bounds[i] = n.Value
+	}
+
+	runes := []rune(str.Value)
+	start := bounds[0]
+	if start > int64(len(runes)) {
+		start = int64(len(runes))
+	}
+	end := int64(len(runes))
+	if len(bounds) == 2 && bounds[1] < end-start {
+		end = start + bounds[1]
+	}
+	return &object.String{Value: string(runes[start:end])}
+}
+
+// lines(s) returns the lines of s, without a last empty one when s ends
+// with a line break.
+func linesBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	values, err := stringArgs("lines", args, 1)
+	if err != nil {
+		return err
+	}
+
+	s := strings.TrimSuffix(values[0], "\n")
+	if s == "" {
+		return &object.Array{Elements: []object.Object{}}
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return stringArray(lines)
+}
+
+// split_words(s) returns the words of s: its runs of letters and digits.
+func splitWordsBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	values, err := stringArgs("split_words", args, 1)
+	if err != nil {
+		return err
+	}
+	return stringArray(strings.FieldsFunc(values[0], func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	}))
+}
+
+// reverse(x) reverses the characters of a string or the elements of an
+// array.
+func reverseBuiltin(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.String:
+		runes := []rune(arg.Value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return &object.String{Value: string(runes)}
+	case *object.Array:
+		n := len(arg.Elements)
+		elements := make([]object.Object, n)
+		for i, elem := range arg.Elements {
+			elements[n-1-i] = elem
+		}
+		return &object.Array{Elements: elements}
+	default:
+		return newError("argument to `reverse` must be STRING or ARRAY, got %s", arg.Type())
+	}
+}
@@ -0,0 +1,108 @@
+package evaluator
+
+import "testing"
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`upper("súcubo")`, "SÚCUBO"},
+		{`lower("ÁRBOL Ñu")`, "árbol ñu"},
+		{`trim("  boato \n")`, "boato"},
+		{`split("a,b,,c", ",")`, []interface{}{"a", "b", "", "c"}},
+		{`split("año", "")`, []interface{}{"a", "ñ", "o"}},
+		{`join(["Alí", "Babá"], " y ")`, "Alí y Babá"},
+		{`join([], ",")`, ""},
+		{`replace("lat. lat.", "lat.", "latín")`, "latín latín"},
+		{`contains("Del lat. boatus.", "lat.")`, true},
+		{`contains("boato", "x")`, false},
+		{`starts_with("épico", "é")`, true},
+		{`ends_with("súcubo", "bo")`, true},
+		{`index("Alí Babá", "Babá")`, 4},
+		{`index("boato", "x")`, -1},
+		{`substr("súcubo", 1, 3)`, "úcu"},
+		{`substr("súcubo", 3)`, "ubo"},
+		{`substr("súcubo", 4, 10)`, "bo"},
+		{`substr("súcubo", 10)`, ""},
+		{`substr("abc", 1, 9223372036854775807)`, "bc"},
+		{`lines("uno\u{d}\ndos\n")`, []interface{}{"uno", "dos"}},
+		{`lines("\nQuizá del occit.\n1. f. Discurso.\n")`, []interface{}{"", "Quizá del occit.", "1. f. Discurso."}},
+		{`lines("")`, []interface{}{}},
+		{`split_words("Del lat. 'boatus', pompa; 2666.")`, []interface{}{"Del", "lat", "boatus", "pompa", "2666"}},
+		{`split_words("")`, []interface{}{}},
+		{`reverse("año")`, "oña"},
+		{`reverse([1, 2, 3])`, []interface{}{3, 2, 1}},
+		{`"súcubo"[1]`, "ú"},
+		{`"súcubo"[5]`, "o"},
+		{`"súcubo"[6]`, nil},
+		{`"súcubo"[-1]`, nil},
+
+		{`upper(1)`, errorMessage("argument to `upper` must be STRING, got INTEGER")},
+		{`contains("a")`, errorMessage("wrong number of arguments. got=1, want=2")},
+		{`join(["a", 1], ",")`, errorMessage("elements of `join` must be STRING, got INTEGER")},
+		{`join("a", ",")`, errorMessage("argument to `join` must be ARRAY, got STRING")},
+		{`substr("a", -1)`, errorMessage("start and length of `substr` must be non-negative INTEGER, got -1")},
+		{`split_words(1)`, errorMessage("argument to `split_words` must be STRING, got INTEGER")},
+		{`reverse(1)`, errorMessage("argument to `reverse` must be STRING or ARRAY, got INTEGER")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestStringBuiltinsOnVocabulary(t *testing.T) {
+	input := `
+word: "boato" {"Del lat. boatus."};
+word: "vodevil" {"Del fr. vaudeville."};
+word: "quid" {"Del lat. quid."};
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`map(filter(words(), fn(w) { contains(w.definition, "lat.") }), fn(w) { upper(w.name) })`, []interface{}{"BOATO", "QUID"}},
+		{`len(split_words(join(map(words(), fn(w) { w.definition }), " ")))`, 9},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	input := `
+word: "boato" {"Del lat. boatus."};
+let w = words()[0];
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"word ${w.name} has ${len(w.definition)} chars"`, "word boato has 16 chars"},
+		{`"${1 + 1}${true}${[1, "a"]}${1.5}"`, "2true[1, a]1.5"},
+		{`"${"a" + "b"}"`, "ab"},
+		{`"<${join(map([1, 2], fn(x) { "${x * 2}" }), ", ")}>"`, "<2, 4>"},
+		{`"${ {"k": "v"}["k"] }"`, "v"},
+		{`let f = fn(x) { "x=${x}" }; f(3)`, "x=3"},
+		{`"${1}"`, "1"},
+		{`"cost \${x}"`, "cost ${x}"},
+		{`"${missing}"`, errorMessage("identifier not found: missing")},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(input + tt.input)
+		if !testObject(t, evaluated, tt.expected) {
+			t.Errorf("wrong result for %q", tt.input)
+		}
+	}
+}
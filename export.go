@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"wordbuilder/object"
+	"wordbuilder/serializer"
+)
+
+// exporters write the knowledge base of a program in one output format.
+var exporters = map[string]func(out io.Writer, kb *object.KnowledgeBase, order object.Order) error{
+	"json": exportJSON,
+	"csv":  exportCSV,
+	"text": exportText,
+	"wb":   exportSource,
+}
+
+func exportCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("export"))
+	format := fs.String("format", "json", "output format: json, csv, text or wb")
+	order := fs.String("order", "definition", "order of the entries: definition, alpha or kind")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	if code := c.parseFlags(fs, args, 1, 1); code >= 0 {
+		return code
+	}
+
+	export, ok := exporters[*format]
+	if !ok {
+		fmt.Fprintf(c.stderr, "wordbuilder export: unknown format %q\n", *format)
+		return exitUsage
+	}
+
+	entryOrder, ok := object.LookupOrder(*order)
+	if !ok {
+		fmt.Fprintf(c.stderr, "wordbuilder export: unknown order %q\n", *order)
+		return exitUsage
+	}
+
+	env, ok := c.loadQuietly(fs.Arg(0))
+	if !ok {
+		return exitError
+	}
+
+	out := c.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "wordbuilder export: %s\n", err)
+			return exitError
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := export(out, env.Knowledge(), entryOrder); err != nil {
+		fmt.Fprintf(c.stderr, "wordbuilder export: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+type jsonEntry struct {
+	Kind       string                 `json:"kind"`
+	Name       string                 `json:"name"`
+	Definition string                 `json:"definition,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+type jsonQuote struct {
+	By         string                 `json:"by"`
+	Text       string                 `json:"text"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// exportJSON writes entries, quotes and thoughts as one JSON object.
+func exportJSON(out io.Writer, kb *object.KnowledgeBase, order object.Order) error {
+	doc := struct {
+		Entries  []jsonEntry `json:"entries"`
+		Quotes   []jsonQuote `json:"quotes"`
+		Thoughts []string    `json:"thoughts"`
+	}{
+		Entries:  []jsonEntry{},
+		Quotes:   []jsonQuote{},
+		Thoughts: kb.Thoughts(),
+	}
+
+	for _, entry := range kb.Entries(order) {
+		doc.Entries = append(doc.Entries, jsonEntry{
+			Kind:       kindName(entry),
+			Name:       entry.Name(),
+			Definition: entry.Text(),
+			Attributes: jsonAttributes(entry.Attributes()),
+		})
+	}
+	for _, q := range kb.Quotes() {
+		doc.Quotes = append(doc.Quotes, jsonQuote{By: q.By, Text: q.Text, Attributes: jsonAttributes(q.Attributes)})
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// jsonAttributes converts attrs to a JSON object, or nil if there are none.
+func jsonAttributes(attrs object.Attributes) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+	values := map[string]interface{}{}
+	for _, attr := range attrs {
+		values[attr.Key] = jsonValue(attr.Value)
+	}
+	return values
+}
+
+// jsonValue converts obj to the value encoding/json writes for it. Objects
+// with no JSON counterpart are written as their Inspect() text.
+func jsonValue(obj object.Object) interface{} {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj.Value
+	case *object.Integer:
+		return obj.Value
+	case *object.BigInteger:
+		return json.Number(obj.Value.String())
+	case *object.Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return obj.Inspect()
+		}
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.Array:
+		values := []interface{}{}
+		for _, el := range obj.Elements {
+			values = append(values, jsonValue(el))
+		}
+		return values
+	case *object.Hash:
+		values := map[string]interface{}{}
+		for _, pair := range obj.Pairs {
+			values[pair.Key.Inspect()] = jsonValue(pair.Value)
+		}
+		return values
+	}
+	return obj.Inspect()
+}
+
+// exportCSV writes one kind,name,definition record per entry.
+func exportCSV(out io.Writer, kb *object.KnowledgeBase, order object.Order) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"kind", "name", "definition"})
+	for _, entry := range kb.Entries(order) {
+		w.Write([]string{kindName(entry), entry.Name(), entry.Text()})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// exportText writes each entry name followed by its indented definition.
+func exportText(out io.Writer, kb *object.KnowledgeBase, order object.Order) error {
+	for _, entry := range kb.Entries(order) {
+		if _, err := fmt.Fprintf(out, "%s (%s)\n", entry.Name(), kindName(entry)); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(strings.TrimSpace(entry.Text()), "\n") {
+			if line != "" {
+				fmt.Fprintf(out, "    %s\n", line)
+			}
+		}
+	}
+	return nil
+}
+
+// exportSource writes the knowledge base as wordbuilder source. Entries are
+// always written in definition order.
+func exportSource(out io.Writer, kb *object.KnowledgeBase, order object.Order) error {
+	return serializer.Write(out, kb)
+}
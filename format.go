@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"wordbuilder/lexer"
+	"wordbuilder/parser"
+	"wordbuilder/printer"
+)
+
+func fmtCommand(c *cli, args []string) int {
+	fs := c.flagSet(lookupCommand("fmt"))
+	list := fs.Bool("l", false, "list the files whose formatting differs instead of rewriting them")
+	if code := c.parseFlags(fs, args, 1, -1); code >= 0 {
+		return code
+	}
+
+	code := exitOK
+	for _, path := range fs.Args() {
+		source, formatted, ok := c.formatFile(path)
+		if !ok {
+			code = exitError
+			continue
+		}
+		if formatted == source {
+			continue
+		}
+
+		if *list {
+			fmt.Fprintln(c.stdout, path)
+			code = exitError
+			continue
+		}
+		info, err := os.Stat(path)
+		if err == nil {
+			err = ioutil.WriteFile(path, []byte(formatted), info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintf(c.stderr, "wordbuilder: %s\n", err)
+			code = exitError
+		}
+	}
+	return code
+}
+
+// formatFile returns the source of the file at path and its canonical
+// formatting. Files that do not parse are reported on stderr.
+func (c *cli) formatFile(path string) (source, formatted string, ok bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "wordbuilder: %s\n", err)
+		return "", "", false
+	}
+
+	source = string(content)
+	p := parser.New(lexer.NewFile(path, source))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		printParseErrors(c.stderr, p.Errors(), source)
+		return source, "", false
+	}
+
+	return source, printer.Source(program), true
+}
@@ -1,191 +1,454 @@
-package lexer
-
-import (
-	"wordbuilder/token"
-)
-
-// Lexer ...
-type Lexer struct {
-	input        string
-	position     int  // current position in input (points to current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
-	lineNumber   int
-}
-
-func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPosition]
-	}
-	l.position = l.readPosition
-	l.readPosition++
-}
-
-// New ...
-func New(input string) *Lexer {
-	l := &Lexer{input: input, lineNumber: 1}
-	l.readChar()
-	return l
-}
-
-// CurrentLine ...
-func (l *Lexer) CurrentLine() int {
-	return l.lineNumber
-}
-
-// NextToken ...
-func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-	l.skipWhitespace()
-	l.skipComments()
-
-	switch l.ch {
-	case '=':
-		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			tok = token.Token{Type: token.Eq, Literal: string(ch) + string(l.ch)}
-		} else {
-			tok = newToken(token.Assign, l.ch)
-		}
-	case '+':
-		tok = newToken(token.Plus, l.ch)
-	case '-':
-		tok = newToken(token.Minus, l.ch)
-	case '!':
-		if l.peekChar() == '=' {
-			ch := l.ch
-			l.readChar()
-			tok = token.Token{Type: token.NotEq, Literal: string(ch) + string(l.ch)}
-		} else {
-			tok = newToken(token.Bang, l.ch)
-		}
-	case '/':
-		tok = newToken(token.Slash, l.ch)
-	case '*':
-		tok = newToken(token.Asterisk, l.ch)
-	case '<':
-		tok = newToken(token.Lt, l.ch)
-	case '>':
-		tok = newToken(token.Gt, l.ch)
-	case ';':
-		tok = newToken(token.Semicolon, l.ch)
-	case '(':
-		tok = newToken(token.LeftParen, l.ch)
-	case ')':
-		tok = newToken(token.RightParen, l.ch)
-	case ',':
-		tok = newToken(token.Comma, l.ch)
-	case '{':
-		tok = newToken(token.LeftBrace, l.ch)
-	case '}':
-		tok = newToken(token.RightBrace, l.ch)
-	case '[':
-		tok = newToken(token.LeftBracket, l.ch)
-	case ']':
-		tok = newToken(token.RightBracket, l.ch)
-	case ':':
-		tok = newToken(token.Colon, l.ch)
-	case '"':
-		tok.Type = token.String
-		tok.Literal = l.readString()
-	case 0:
-		tok.Literal = ""
-		tok.Type = token.EOF
-	default:
-		if isLetter(l.ch) {
-			tok.Literal = l.readIdentifier()
-			tok.Type = token.LookupIdent(tok.Literal)
-			return tok
-		} else if isDigit(l.ch) {
-			tok.Type = token.Int
-			tok.Literal = l.readNumber()
-			return tok
-		} else {
-			tok = newToken(token.Illegal, l.ch)
-		}
-	}
-
-	l.readChar()
-	return tok
-}
-
-func (l *Lexer) readString() string {
-	position := l.position + 1
-	for {
-		l.readChar()
-		if l.ch == '"' {
-			break
-		}
-	}
-	return l.input[position:l.position]
-}
-
-func (l *Lexer) readNumber() string {
-	position := l.position
-	for isDigit(l.ch) {
-		l.readChar()
-	}
-	return l.input[position:l.position]
-}
-
-func isDigit(ch byte) bool {
-	return '0' <= ch && ch <= '9'
-}
-
-func (l *Lexer) skipWhitespace() {
-whitespaces:
-	for {
-		switch l.ch {
-		case ' ', '\t':
-			l.readChar()
-		case '\n', '\r':
-			l.readChar()
-			l.lineNumber++
-		default:
-			break whitespaces
-		}
-	}
-}
-
-func (l *Lexer) skipComments() {
-	if l.ch == '#' {
-		l.readChar()
-		for l.ch != '\n' && l.ch != '\r' {
-			l.readChar()
-		}
-		l.readChar()
-		l.readChar()
-	}
-}
-
-func newToken(tokenType token.Type, ch byte) token.Token {
-	return token.Token{Type: tokenType, Literal: string(ch)}
-}
-
-func (l *Lexer) readIdentifier() string {
-	position := l.position
-
-	if isLetter(l.ch) && !isDigit(l.ch) {
-		l.readChar()
-		for isLetter(l.ch) || isDigit(l.ch) {
-			l.readChar()
-		}
-	}
-
-	return l.input[position:l.position]
-}
-
-func isLetter(ch byte) bool {
-	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || (ch == '_')
-}
-
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
-	}
-	return l.input[l.readPosition]
-
-}
+package lexer
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"wordbuilder/token"
+)
+
+// Lexer ...
+type Lexer struct {
+	input        string
+	position     int  // current position in input (byte offset of current char)
+	readPosition int  // current reading position in input (after current char)
+	ch           rune // current char under examination
+	lineNumber   int
+	column       int           // column of the current char, counted in characters
+	file         string        // name reported in token positions
+	comments     []token.Token // comments skipped so far
+
+	// interpolations holds the "${...}" expressions being read, innermost
+	// last.
+	interpolations []interpolation
+}
+
+// interpolation is a "${...}" expression being read: the offset and
+// position of the string it is embedded in and how many braces it has opened
+// so far.
+type interpolation struct {
+	start int
+	pos   token.Position
+	depth int
+}
+
+func (l *Lexer) readChar() {
+	if l.readPosition > len(l.input) {
+		return // already at the end of the input
+	}
+	if l.ch == '\n' {
+		l.lineNumber++
+		l.column = 0
+	}
+
+	if l.readPosition >= len(l.input) {
+		l.ch = 0
+		l.position = len(l.input)
+		l.readPosition = len(l.input) + 1
+	} else {
+		r, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		l.ch = r
+		l.position = l.readPosition
+		l.readPosition += width
+	}
+	l.column++
+}
+
+// New ...
+func New(input string) *Lexer {
+	return NewFile("", input)
+}
+
+// NewFile returns a lexer whose token positions name the given file.
+func NewFile(file, input string) *Lexer {
+	l := &Lexer{input: input, lineNumber: 1, file: file}
+	l.readChar()
+	return l
+}
+
+// File ...
+func (l *Lexer) File() string {
+	return l.file
+}
+
+// CurrentLine ...
+func (l *Lexer) CurrentLine() int {
+	return l.lineNumber
+}
+
+// CurrentColumn returns the column of the character under examination,
+// counted in characters rather than bytes.
+func (l *Lexer) CurrentColumn() int {
+	return l.column
+}
+
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{File: l.file, Line: l.lineNumber, Column: l.column}
+}
+
+// NextToken ...
+func (l *Lexer) NextToken() token.Token {
+	l.skipWhitespace()
+	for l.ch == '#' {
+		l.readComment()
+		l.skipWhitespace()
+	}
+
+	start := l.currentPosition()
+	tok := l.readToken()
+	if !tok.Pos.IsValid() {
+		tok.Pos = start
+	}
+	tok.End = l.currentPosition()
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
+
+	switch l.ch {
+	case '=':
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.Eq, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.Assign, l.ch)
+		}
+	case '+':
+		tok = newToken(token.Plus, l.ch)
+	case '-':
+		tok = newToken(token.Minus, l.ch)
+	case '!':
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.NotEq, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.Bang, l.ch)
+		}
+	case '/':
+		tok = newToken(token.Slash, l.ch)
+	case '*':
+		tok = newToken(token.Asterisk, l.ch)
+	case '%':
+		tok = newToken(token.Percent, l.ch)
+	case '<':
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.LtEq, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.Lt, l.ch)
+		}
+	case '>':
+		if l.peekChar() == '=' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.GtEq, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.Gt, l.ch)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.And, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.Illegal, l.ch)
+		}
+	case '|':
+		if l.peekChar() == '|' {
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.Or, Literal: string(ch) + string(l.ch)}
+		} else {
+			tok = newToken(token.Illegal, l.ch)
+		}
+	case ';':
+		tok = newToken(token.Semicolon, l.ch)
+	case '(':
+		tok = newToken(token.LeftParen, l.ch)
+	case ')':
+		tok = newToken(token.RightParen, l.ch)
+	case ',':
+		tok = newToken(token.Comma, l.ch)
+	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].depth++
+		}
+		tok = newToken(token.LeftBrace, l.ch)
+	case '}':
+		n := len(l.interpolations)
+		if n > 0 && l.interpolations[n-1].depth == 0 {
+			str := l.interpolations[n-1]
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringText(str.start, str.pos, token.StringMiddle, token.StringTail)
+			break
+		}
+		if n > 0 {
+			l.interpolations[n-1].depth--
+		}
+		tok = newToken(token.RightBrace, l.ch)
+	case '[':
+		tok = newToken(token.LeftBracket, l.ch)
+	case ']':
+		tok = newToken(token.RightBracket, l.ch)
+	case ':':
+		tok = newToken(token.Colon, l.ch)
+	case '.':
+		tok = newToken(token.Dot, l.ch)
+	case '"':
+		tok = l.readString()
+	case 0:
+		tok.Literal = ""
+		tok.Type = token.EOF
+	default:
+		if isLetter(l.ch) {
+			tok.Literal = l.readIdentifier()
+			tok.Type = token.LookupIdent(tok.Literal)
+			return tok
+		} else if isDigit(l.ch) {
+			tok.Literal, tok.Type = l.readNumber()
+			return tok
+		} else {
+			tok = newToken(token.Illegal, l.ch)
+		}
+	}
+
+	l.readChar()
+	return tok
+}
+
+// readString reads the string literal starting at the current '"' and
+// leaves the lexer on its closing '"'. A string opened with three quotes is
+// raw and ends at the next three quotes; other strings may use the escapes
+// \", \\, \$, \n, \t and \u{...} and embed expressions between "${" and
+// "}". Strings of both kinds can span several lines; their CRLF line breaks
+// are read as LF.
+//
+// An unterminated string is returned as an ILLEGAL token holding the rest of
+// the input. An invalid escape is returned as a BAD_ESCAPE token holding the
+// escape, at its position.
+func (l *Lexer) readString() token.Token {
+	start := l.position
+	if strings.HasPrefix(l.input[start:], `"""`) {
+		return l.readRawString()
+	}
+	return l.readStringText(start, l.currentPosition(), token.StringHead, token.String)
+}
+
+// readStringText reads the text of the string opened at offset start and
+// position pos, from the character after the current '"' or '}'. If the text
+// ends at a "${" it is returned as a token of type open and the lexer is left
+// on its '{'; otherwise it is returned as a token of type closed and the
+// lexer is left on the closing '"'.
+func (l *Lexer) readStringText(start int, pos token.Position, open, closed token.Type) token.Token {
+	var out strings.Builder
+	for {
+		l.readChar()
+		switch {
+		case l.atEOF():
+			return token.Token{Type: token.Illegal, Literal: l.input[start:], Pos: pos}
+		case l.ch == '"':
+			return token.Token{Type: closed, Literal: out.String()}
+		case l.ch == '$' && l.peekChar() == '{':
+			l.readChar()
+			l.interpolations = append(l.interpolations, interpolation{start: start, pos: pos})
+			return token.Token{Type: open, Literal: out.String()}
+		case l.ch == '\\':
+			escapePos, escapeStart := l.currentPosition(), l.position
+			r, ok := l.readEscape()
+			if !ok {
+				escape := l.input[escapeStart:l.position]
+				l.skipString()
+				if l.atEOF() {
+					return token.Token{Type: token.Illegal, Literal: l.input[start:], Pos: pos}
+				}
+				return token.Token{Type: token.BadEscape, Literal: escape, Pos: escapePos}
+			}
+			out.WriteRune(r)
+		case l.ch == '\r' && l.peekChar() == '\n':
+			// CRLF line breaks are read as LF, like those of raw strings.
+		default:
+			out.WriteString(l.input[l.position:l.readPosition])
+		}
+	}
+}
+
+func (l *Lexer) readRawString() token.Token {
+	start := l.position
+	l.readChar()
+	l.readChar()
+	l.readChar()
+
+	content := l.position
+	for !strings.HasPrefix(l.input[l.position:], `"""`) {
+		if l.atEOF() {
+			return token.Token{Type: token.Illegal, Literal: l.input[start:]}
+		}
+		l.readChar()
+	}
+	value := strings.Replace(l.input[content:l.position], "\r\n", "\n", -1)
+	l.readChar()
+	l.readChar()
+
+	return token.Token{Type: token.String, Literal: value}
+}
+
+// readEscape reads the escape sequence starting at the current '\\'. On
+// success it leaves the lexer on the last character of the sequence; on
+// failure, right after the character that makes it invalid, unless that is
+// the closing '"'.
+func (l *Lexer) readEscape() (rune, bool) {
+	invalid := func() (rune, bool) {
+		if l.ch != '"' && !l.atEOF() {
+			l.readChar()
+		}
+		return 0, false
+	}
+
+	l.readChar()
+	switch l.ch {
+	case '"':
+		return '"', true
+	case '\\':
+		return '\\', true
+	case '$':
+		return '$', true
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'u':
+	default:
+		return invalid()
+	}
+
+	l.readChar()
+	if l.ch != '{' {
+		return invalid()
+	}
+	digits := l.position + 1
+	for l.readChar(); isHexDigit(l.ch) && l.position-digits < 6; l.readChar() {
+	}
+	if l.ch != '}' || l.position == digits {
+		return invalid()
+	}
+
+	code, _ := strconv.ParseInt(l.input[digits:l.position], 16, 32)
+	if !utf8.ValidRune(rune(code)) {
+		return invalid()
+	}
+	return rune(code), true
+}
+
+// skipString moves to the '"' closing the current string, or to the end of
+// the input.
+func (l *Lexer) skipString() {
+	for l.ch != '"' && !l.atEOF() {
+		if l.ch == '\\' {
+			l.readChar()
+		}
+		l.readChar()
+	}
+}
+
+func (l *Lexer) atEOF() bool {
+	return l.position >= len(l.input)
+}
+
+// readNumber reads an integer, or a float when the digits are followed by a
+// '.' and more digits. A '.' followed by anything else is left for the dot
+// operator.
+func (l *Lexer) readNumber() (string, token.Type) {
+	position := l.position
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	if l.ch != '.' || !isDigit(l.peekChar()) {
+		return l.input[position:l.position], token.Int
+	}
+
+	l.readChar()
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	return l.input[position:l.position], token.Float
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+func (l *Lexer) skipWhitespace() {
+whitespaces:
+	for {
+		switch l.ch {
+		case ' ', '\t', '\n', '\r':
+			l.readChar()
+		default:
+			break whitespaces
+		}
+	}
+}
+
+// readComment records the comment starting at the current '#', up to the end
+// of the line.
+func (l *Lexer) readComment() {
+	start := l.currentPosition()
+	position := l.position
+	for l.ch != '\n' && l.ch != '\r' && l.ch != 0 {
+		l.readChar()
+	}
+
+	l.comments = append(l.comments, token.Token{
+		Type:    token.Comment,
+		Literal: l.input[position:l.position],
+		Pos:     start,
+		End:     l.currentPosition(),
+	})
+}
+
+// Comments returns the comments read so far, in source order. Comments are
+// not returned by NextToken.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+func newToken(tokenType token.Type, ch rune) token.Token {
+	return token.Token{Type: tokenType, Literal: string(ch)}
+}
+
+func (l *Lexer) readIdentifier() string {
+	position := l.position
+
+	if isLetter(l.ch) && !isDigit(l.ch) {
+		l.readChar()
+		// Combining marks keep decomposed accents (e.g. "u" + U+0301) inside
+		// the identifier.
+		for isLetter(l.ch) || isDigit(l.ch) || unicode.IsMark(l.ch) {
+			l.readChar()
+		}
+	}
+
+	return l.input[position:l.position]
+}
+
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+func (l *Lexer) peekChar() rune {
+	if l.readPosition >= len(l.input) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return r
+}
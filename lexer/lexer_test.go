@@ -1,252 +1,565 @@
-package lexer
-
-import (
-	"strings"
-	"testing"
-	"wordbuilder/token"
-)
-
-func TestNextToken(t *testing.T) {
-	input := `let five = 5;
-let ten = 10;
-
-let add = fn(x, y) {
-  x + y;
-};
-
-let result = add(five, ten);
-!-/*5;
-5 < 10 > 5;
-
-if (5 < 10) {
-    return true;
-} else {
-	return false;
-}
-10 == 10;
-10 != 9;
-"foobar"
-"foo bar"
-[1, 2];
-{"foo": "bar"}
-me;
-tr;
-word;
-cpt;
-ref;
-`
-
-	tests := []struct {
-		expectedType    token.Type
-		expectedLiteral string
-	}{
-		{token.Let, "let"},
-		{token.Ident, "five"},
-		{token.Assign, "="},
-		{token.Int, "5"},
-		{token.Semicolon, ";"},
-		{token.Let, "let"},
-		{token.Ident, "ten"},
-		{token.Assign, "="},
-		{token.Int, "10"},
-		{token.Semicolon, ";"},
-		{token.Let, "let"},
-		{token.Ident, "add"},
-		{token.Assign, "="},
-		{token.Function, "fn"},
-		{token.LeftParen, "("},
-		{token.Ident, "x"},
-		{token.Comma, ","},
-		{token.Ident, "y"},
-		{token.RightParen, ")"},
-		{token.LeftBrace, "{"},
-		{token.Ident, "x"},
-		{token.Plus, "+"},
-		{token.Ident, "y"},
-		{token.Semicolon, ";"},
-		{token.RightBrace, "}"},
-		{token.Semicolon, ";"},
-		{token.Let, "let"},
-		{token.Ident, "result"},
-		{token.Assign, "="},
-		{token.Ident, "add"},
-		{token.LeftParen, "("},
-		{token.Ident, "five"},
-		{token.Comma, ","},
-		{token.Ident, "ten"},
-		{token.RightParen, ")"},
-		{token.Semicolon, ";"},
-		{token.Bang, "!"},
-		{token.Minus, "-"},
-		{token.Slash, "/"},
-		{token.Asterisk, "*"},
-		{token.Int, "5"},
-		{token.Semicolon, ";"},
-		{token.Int, "5"},
-		{token.Lt, "<"},
-		{token.Int, "10"},
-		{token.Gt, ">"},
-		{token.Int, "5"},
-		{token.Semicolon, ";"},
-		{token.If, "if"},
-		{token.LeftParen, "("},
-		{token.Int, "5"},
-		{token.Lt, "<"},
-		{token.Int, "10"},
-		{token.RightParen, ")"},
-		{token.LeftBrace, "{"},
-		{token.Return, "return"},
-		{token.True, "true"},
-		{token.Semicolon, ";"},
-		{token.RightBrace, "}"},
-		{token.Else, "else"},
-		{token.LeftBrace, "{"},
-		{token.Return, "return"},
-		{token.False, "false"},
-		{token.Semicolon, ";"},
-		{token.RightBrace, "}"},
-		{token.Int, "10"},
-		{token.Eq, "=="},
-		{token.Int, "10"},
-		{token.Semicolon, ";"},
-
-		{token.Int, "10"},
-		{token.NotEq, "!="},
-		{token.Int, "9"},
-		{token.Semicolon, ";"},
-
-		{token.String, "foobar"},
-		{token.String, "foo bar"},
-
-		{token.LeftBracket, "["},
-		{token.Int, "1"},
-		{token.Comma, ","},
-		{token.Int, "2"},
-		{token.RightBracket, "]"},
-		{token.Semicolon, ";"},
-
-		{token.LeftBrace, "{"},
-		{token.String, "foo"},
-		{token.Colon, ":"},
-		{token.String, "bar"},
-		{token.RightBrace, "}"},
-
-		{token.Me, "me"},
-		{token.Semicolon, ";"},
-
-		{token.Tr, "tr"},
-		{token.Semicolon, ";"},
-
-		{token.Word, "word"},
-		{token.Semicolon, ";"},
-
-		{token.Cpt, "cpt"},
-		{token.Semicolon, ";"},
-
-		{token.Ref, "ref"},
-		{token.Semicolon, ";"},
-
-		{token.EOF, ""},
-	}
-
-	l := New(input)
-
-	for i, tt := range tests {
-		tok := l.NextToken()
-
-		if tok.Type != tt.expectedType {
-			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
-				i, tt.expectedType, tok.Type)
-		}
-
-		if tok.Literal != tt.expectedLiteral {
-			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
-				i, tt.expectedLiteral, tok.Literal)
-		}
-	}
-
-}
-
-func TestLineNumber(t *testing.T) {
-	input := `
-		let five = 5;
-	
-	
-		let ten = 10;
-	
-	
-`
-	l := New(input)
-
-	tests := []struct {
-		expectedType    token.Type
-		expectedLiteral string
-	}{
-		{token.Let, "let"},
-		{token.Ident, "five"},
-		{token.Assign, "="},
-		{token.Int, "5"},
-		{token.Semicolon, ";"},
-		{token.Let, "let"},
-		{token.Ident, "ten"},
-		{token.Assign, "="},
-		{token.Int, "10"},
-		{token.Semicolon, ";"},
-		{token.EOF, ""},
-	}
-
-	for i, tt := range tests {
-		tok := l.NextToken()
-
-		if tok.Type != tt.expectedType {
-			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
-				i, tt.expectedType, tok.Type)
-		}
-
-		if tok.Literal != tt.expectedLiteral {
-			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
-				i, tt.expectedLiteral, tok.Literal)
-		}
-	}
-
-	inputLineNumberCount := len(strings.Split(input, "\n"))
-
-	if inputLineNumberCount != l.lineNumber {
-		t.Fatalf("wrong line number, expecting: %d, got: %d\n", inputLineNumberCount, l.lineNumber)
-	}
-
-}
-
-func TestNextTokenWithComments(t *testing.T) {
-	input := `let five = 5+ # this is a comment
-	3;`
-	tests := []struct {
-		expectedType    token.Type
-		expectedLiteral string
-	}{
-		{token.Let, "let"},
-		{token.Ident, "five"},
-		{token.Assign, "="},
-		{token.Int, "5"},
-		{token.Plus, "+"},
-		{token.Int, "3"},
-		{token.Semicolon, ";"},
-	}
-
-	l := New(input)
-
-	for i, tt := range tests {
-		tok := l.NextToken()
-
-		if tok.Type != tt.expectedType {
-			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
-				i, tt.expectedType, tok.Type)
-		}
-
-		if tok.Literal != tt.expectedLiteral {
-			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
-				i, tt.expectedLiteral, tok.Literal)
-		}
-	}
-
-}
+package lexer
+
+import (
+	"strings"
+	"testing"
+	"wordbuilder/literal"
+	"wordbuilder/token"
+)
+
+func TestNextToken(t *testing.T) {
+	input := `let five = 5;
+let ten = 10;
+
+let add = fn(x, y) {
+  x + y;
+};
+
+let result = add(five, ten);
+!-/*5;
+5 < 10 > 5;
+
+if (5 < 10) {
+    return true;
+} else {
+	return false;
+}
+10 == 10;
+10 != 9;
+"foobar"
+"foo bar"
+[1, 2];
+{"foo": "bar"}
+me;
+tr;
+word;
+cpt;
+ref;
+`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.Let, "let"},
+		{token.Ident, "five"},
+		{token.Assign, "="},
+		{token.Int, "5"},
+		{token.Semicolon, ";"},
+		{token.Let, "let"},
+		{token.Ident, "ten"},
+		{token.Assign, "="},
+		{token.Int, "10"},
+		{token.Semicolon, ";"},
+		{token.Let, "let"},
+		{token.Ident, "add"},
+		{token.Assign, "="},
+		{token.Function, "fn"},
+		{token.LeftParen, "("},
+		{token.Ident, "x"},
+		{token.Comma, ","},
+		{token.Ident, "y"},
+		{token.RightParen, ")"},
+		{token.LeftBrace, "{"},
+		{token.Ident, "x"},
+		{token.Plus, "+"},
+		{token.Ident, "y"},
+		{token.Semicolon, ";"},
+		{token.RightBrace, "}"},
+		{token.Semicolon, ";"},
+		{token.Let, "let"},
+		{token.Ident, "result"},
+		{token.Assign, "="},
+		{token.Ident, "add"},
+		{token.LeftParen, "("},
+		{token.Ident, "five"},
+		{token.Comma, ","},
+		{token.Ident, "ten"},
+		{token.RightParen, ")"},
+		{token.Semicolon, ";"},
+		{token.Bang, "!"},
+		{token.Minus, "-"},
+		{token.Slash, "/"},
+		{token.Asterisk, "*"},
+		{token.Int, "5"},
+		{token.Semicolon, ";"},
+		{token.Int, "5"},
+		{token.Lt, "<"},
+		{token.Int, "10"},
+		{token.Gt, ">"},
+		{token.Int, "5"},
+		{token.Semicolon, ";"},
+		{token.If, "if"},
+		{token.LeftParen, "("},
+		{token.Int, "5"},
+		{token.Lt, "<"},
+		{token.Int, "10"},
+		{token.RightParen, ")"},
+		{token.LeftBrace, "{"},
+		{token.Return, "return"},
+		{token.True, "true"},
+		{token.Semicolon, ";"},
+		{token.RightBrace, "}"},
+		{token.Else, "else"},
+		{token.LeftBrace, "{"},
+		{token.Return, "return"},
+		{token.False, "false"},
+		{token.Semicolon, ";"},
+		{token.RightBrace, "}"},
+		{token.Int, "10"},
+		{token.Eq, "=="},
+		{token.Int, "10"},
+		{token.Semicolon, ";"},
+
+		{token.Int, "10"},
+		{token.NotEq, "!="},
+		{token.Int, "9"},
+		{token.Semicolon, ";"},
+
+		{token.String, "foobar"},
+		{token.String, "foo bar"},
+
+		{token.LeftBracket, "["},
+		{token.Int, "1"},
+		{token.Comma, ","},
+		{token.Int, "2"},
+		{token.RightBracket, "]"},
+		{token.Semicolon, ";"},
+
+		{token.LeftBrace, "{"},
+		{token.String, "foo"},
+		{token.Colon, ":"},
+		{token.String, "bar"},
+		{token.RightBrace, "}"},
+
+		{token.Me, "me"},
+		{token.Semicolon, ";"},
+
+		{token.Tr, "tr"},
+		{token.Semicolon, ";"},
+
+		{token.Word, "word"},
+		{token.Semicolon, ";"},
+
+		{token.Cpt, "cpt"},
+		{token.Semicolon, ";"},
+
+		{token.Ref, "ref"},
+		{token.Semicolon, ";"},
+
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+}
+
+func TestOperators(t *testing.T) {
+	input := `a <= b >= c < d > e && f || g % h & |`
+
+	expected := []token.Type{
+		token.Ident, token.LtEq, token.Ident, token.GtEq, token.Ident, token.Lt,
+		token.Ident, token.Gt, token.Ident, token.And, token.Ident, token.Or,
+		token.Ident, token.Percent, token.Ident, token.Illegal, token.Illegal,
+		token.EOF,
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q (%q)", i, tt, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `3.14 10 1.x xs[0].name 2.`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.Float, "3.14"},
+		{token.Int, "10"},
+		{token.Int, "1"},
+		{token.Dot, "."},
+		{token.Ident, "x"},
+		{token.Ident, "xs"},
+		{token.LeftBracket, "["},
+		{token.Int, "0"},
+		{token.RightBracket, "]"},
+		{token.Dot, "."},
+		{token.Ident, "name"},
+		{token.Int, "2"},
+		{token.Dot, "."},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%q %q, got=%q %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestLineNumber(t *testing.T) {
+	input := `
+		let five = 5;
+	
+	
+		let ten = 10;
+	
+	
+`
+	l := New(input)
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.Let, "let"},
+		{token.Ident, "five"},
+		{token.Assign, "="},
+		{token.Int, "5"},
+		{token.Semicolon, ";"},
+		{token.Let, "let"},
+		{token.Ident, "ten"},
+		{token.Assign, "="},
+		{token.Int, "10"},
+		{token.Semicolon, ";"},
+		{token.EOF, ""},
+	}
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	inputLineNumberCount := len(strings.Split(input, "\n"))
+
+	if inputLineNumberCount != l.lineNumber {
+		t.Fatalf("wrong line number, expecting: %d, got: %d\n", inputLineNumberCount, l.lineNumber)
+	}
+
+}
+
+func TestNextTokenWithComments(t *testing.T) {
+	input := `let five = 5+ # this is a comment
+	3;`
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.Let, "let"},
+		{token.Ident, "five"},
+		{token.Assign, "="},
+		{token.Int, "5"},
+		{token.Plus, "+"},
+		{token.Int, "3"},
+		{token.Semicolon, ";"},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+}
+
+func TestComments(t *testing.T) {
+	input := "# first\nlet a = 1; # second\r\n#third"
+
+	l := New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"# first", 1, 1},
+		{"# second", 2, 12},
+		{"#third", 3, 1},
+	}
+
+	comments := l.Comments()
+	if len(comments) != len(expected) {
+		t.Fatalf("wrong number of comments. expected=%d, got=%d", len(expected), len(comments))
+	}
+	for i, want := range expected {
+		c := comments[i]
+		if c.Type != token.Comment || c.Literal != want.literal ||
+			c.Pos.Line != want.line || c.Pos.Column != want.column {
+			t.Errorf("comments[%d] wrong. expected %q at %d:%d, got %s %q at %d:%d",
+				i, want.literal, want.line, want.column, c.Type, c.Literal, c.Pos.Line, c.Pos.Column)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{`"plain"`, token.String, "plain"},
+		{`"dijo \"hola\""`, token.String, `dijo "hola"`},
+		{`"a\\b\nc\td"`, token.String, "a\\b\nc\td"},
+		{`"\u{e9}\u{1F600}"`, token.String, "é😀"},
+		{"\"two\nlines\"", token.String, "two\nlines"},
+		{`""`, token.String, ""},
+		{`"""raw "quoted" \n"""`, token.String, `raw "quoted" \n`},
+		{"\"\"\"\n1. f. \"Arenga\".\n\"\"\"", token.String, "\n1. f. \"Arenga\".\n"},
+		{`"unterminated`, token.Illegal, `"unterminated`},
+		{`"""unterminated"`, token.Illegal, `"""unterminated"`},
+		{`"trailing \`, token.Illegal, `"trailing \`},
+		{`"bad \q escape"`, token.BadEscape, `\q`},
+		{`"bad \u{110000}"`, token.BadEscape, `\u{110000}`},
+		{`"bad \u{}"`, token.BadEscape, `\u{}`},
+		{`"cost \$5 \${x}"`, token.String, "cost $5 ${x}"},
+		{`"$ and {}"`, token.String, "$ and {}"},
+		{`"word ${`, token.StringHead, "word "},
+	}
+
+	for i, tt := range tests {
+		tok := New(tt.input).NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Errorf("tests[%d] - wrong token for %s. expected=%s %q, got=%s %q",
+				i, tt.input, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestStringFollowedByTokens(t *testing.T) {
+	// After a string, including one with an invalid escape, lexing goes on
+	// after its closing quote.
+	l := New(`"a\"b" "c\qd" ; """e""" ;`)
+	expected := []token.Type{token.String, token.BadEscape, token.Semicolon, token.String, token.Semicolon, token.EOF}
+	for i, want := range expected {
+		if tok := l.NextToken(); tok.Type != want {
+			t.Fatalf("tokens[%d] - wrong type. expected=%s, got=%s %q", i, want, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	input := `"word ${w.name} has ${ {"a": len("${x}")}["a"] } chars" ; "a ${x`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.StringHead, "word "},
+		{token.Ident, "w"},
+		{token.Dot, "."},
+		{token.Ident, "name"},
+		{token.StringMiddle, " has "},
+		{token.LeftBrace, "{"},
+		{token.String, "a"},
+		{token.Colon, ":"},
+		{token.Ident, "len"},
+		{token.LeftParen, "("},
+		{token.StringHead, ""},
+		{token.Ident, "x"},
+		{token.StringTail, ""},
+		{token.RightParen, ")"},
+		{token.RightBrace, "}"},
+		{token.LeftBracket, "["},
+		{token.String, "a"},
+		{token.RightBracket, "]"},
+		{token.StringTail, " chars"},
+		{token.Semicolon, ";"},
+		{token.StringHead, "a "},
+		{token.Ident, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestUnterminatedInterpolatedString(t *testing.T) {
+	// The rest of an unterminated string is reported from its opening quote.
+	l := New(`puts("a ${x} b`)
+	expected := []token.Type{token.Ident, token.LeftParen, token.StringHead, token.Ident, token.Illegal}
+	for i, want := range expected {
+		tok := l.NextToken()
+		if tok.Type != want {
+			t.Fatalf("tokens[%d] - wrong type. expected=%s, got=%s %q", i, want, tok.Type, tok.Literal)
+		}
+		if want == token.Illegal && tok.Literal != `"a ${x} b` {
+			t.Errorf("wrong literal. expected=%q, got=%q", `"a ${x} b`, tok.Literal)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain", `"plain"`},
+		{`dijo "hola" \ adiós`, `"dijo \"hola\" \\ adiós"`},
+		{"two\nlines", "\"two\nlines\""},
+		{"two \"quoted\"\nlines", "\"\"\"two \"quoted\"\nlines\"\"\""},
+		{"ends with a\n\"", "\"ends with a\n\\\"\""},
+		{"costs ${x} or $5", `"costs \${x} or $5"`},
+	}
+
+	for _, tt := range tests {
+		quoted := literal.Quote(tt.input)
+		if quoted != tt.expected {
+			t.Errorf("Quote(%q) wrong. expected=%q, got=%q", tt.input, tt.expected, quoted)
+		}
+		if tok := New(quoted).NextToken(); tok.Type != token.String || tok.Literal != tt.input {
+			t.Errorf("Quote(%q) does not read back. got=%s %q", tt.input, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNextTokenUnicode(t *testing.T) {
+	input := `let súcubo = "Alí Babá";
+año + niño_2;
+# comentario con acentos: ñandú
+ref: "Cueva de Alí Babá";`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.Let, "let"},
+		{token.Ident, "súcubo"},
+		{token.Assign, "="},
+		{token.String, "Alí Babá"},
+		{token.Semicolon, ";"},
+		{token.Ident, "año"},
+		{token.Plus, "+"},
+		{token.Ident, "niño_2"},
+		{token.Semicolon, ";"},
+		{token.Ref, "ref"},
+		{token.Colon, ":"},
+		{token.String, "Cueva de Alí Babá"},
+		{token.Semicolon, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestColumnCountsCharacters(t *testing.T) {
+	l := New("súcubo ñ")
+
+	l.NextToken()
+	if l.CurrentColumn() != 7 {
+		t.Fatalf("wrong column after identifier, expecting: %d, got: %d", 7, l.CurrentColumn())
+	}
+
+	tok := l.NextToken()
+	if tok.Literal != "ñ" {
+		t.Fatalf("literal wrong. expected=%q, got=%q", "ñ", tok.Literal)
+	}
+	if l.CurrentColumn() != 9 {
+		t.Fatalf("wrong column at end of input, expecting: %d, got: %d", 9, l.CurrentColumn())
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := `word: "arenga" {"
+Quizá del occit.
+"};
+let año = 5;`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedPos     token.Position
+		expectedEnd     token.Position
+	}{
+		{"word", token.Position{File: "a.wb", Line: 1, Column: 1}, token.Position{File: "a.wb", Line: 1, Column: 5}},
+		{":", token.Position{File: "a.wb", Line: 1, Column: 5}, token.Position{File: "a.wb", Line: 1, Column: 6}},
+		{"arenga", token.Position{File: "a.wb", Line: 1, Column: 7}, token.Position{File: "a.wb", Line: 1, Column: 15}},
+		{"{", token.Position{File: "a.wb", Line: 1, Column: 16}, token.Position{File: "a.wb", Line: 1, Column: 17}},
+		{"\nQuizá del occit.\n", token.Position{File: "a.wb", Line: 1, Column: 17}, token.Position{File: "a.wb", Line: 3, Column: 2}},
+		{"}", token.Position{File: "a.wb", Line: 3, Column: 2}, token.Position{File: "a.wb", Line: 3, Column: 3}},
+		{";", token.Position{File: "a.wb", Line: 3, Column: 3}, token.Position{File: "a.wb", Line: 3, Column: 4}},
+		{"let", token.Position{File: "a.wb", Line: 4, Column: 1}, token.Position{File: "a.wb", Line: 4, Column: 4}},
+		{"año", token.Position{File: "a.wb", Line: 4, Column: 5}, token.Position{File: "a.wb", Line: 4, Column: 8}},
+	}
+
+	l := NewFile("a.wb", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%s, got=%s", i, tt.expectedPos, tok.Pos)
+		}
+
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%s, got=%s", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+// Package literal writes values as source literals.
+package literal
+
+import "strings"
+
+// Quote returns s as a string literal that the lexer reads back as s: between
+// double quotes with '"', '\\', "${" and carriage returns escaped or, if s
+// spans several lines and contains '"', between three quotes. Line breaks are
+// written as they are.
+func Quote(s string) string {
+	if strings.Contains(s, "\n") && strings.Contains(s, `"`) && !strings.Contains(s, "\r") &&
+		!strings.Contains(s, `"""`) && !strings.HasSuffix(s, `"`) {
+		return `"""` + s + `"""`
+	}
+	return `"` + Escape(s) + `"`
+}
+
+// Escape returns s escaped to be written between the double quotes of a
+// string literal.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `${`, `\${`, "\r", `\u{d}`)
@@ -1,54 +1,208 @@
-package main
-
-import (
-	"io"
-	"io/ioutil"
-	"log"
-	"os"
-	"wordbuilder/evaluator"
-	"wordbuilder/lexer"
-	"wordbuilder/object"
-	"wordbuilder/parser"
-)
-
-func main() {
-
-	args := os.Args
-	if len(args) <= 1 {
-		log.Fatal("wrong number of arguments ... ")
-	}
-
-	fileArg := os.Args[1]
-	programFile, err := os.Open(fileArg)
-
-	if err != nil {
-		log.Fatal("error opening file ... ", err)
-	}
-
-	defer programFile.Close()
-
-	programContent, _ := ioutil.ReadAll(programFile)
-
-	l := lexer.New(string(programContent))
-	p := parser.New(l)
-	env := object.NewEnvironment()
-
-	program := p.ParseProgram()
-
-	if len(p.Errors()) != 0 {
-		printParseErrors(os.Stdout, p.Errors())
-		os.Exit(1)
-	}
-
-	evaluated := evaluator.Eval(program, env)
-	if evaluated != nil {
-		io.WriteString(os.Stdout, evaluated.Inspect())
-		io.WriteString(os.Stdout, "\n")
-	}
-}
-
-func printParseErrors(out io.Writer, errors []parser.Error) {
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg.String()+"\n")
-	}
-}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"wordbuilder/ast"
+	"wordbuilder/evaluator"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+	"wordbuilder/token"
+)
+
+// Exit codes of the wordbuilder command.
+const (
+	exitOK    = 0 // success
+	exitError = 1 // the program failed to parse or run, or a search found nothing
+	exitUsage = 2 // bad command line
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(cli *cli, args []string) int
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"run", "run file.wb", "evaluate a program", runCommand},
+		{"repl", "repl [file.wb]", "start an interactive session, optionally preloading a file", replCommand},
+		{"check", "check file.wb...", "parse files and report syntax errors without running them", checkCommand},
+		{"stats", "stats file.wb", "count the entries defined by a program", statsCommand},
+		{"search", "search [flags] term file.wb", "list the entries whose name contains term", searchCommand},
+		{"export", "export [flags] file.wb", "write the entries defined by a program as json, csv, text or wordbuilder source", exportCommand},
+		{"fmt", "fmt [-l] file.wb...", "rewrite files in the canonical source format", fmtCommand},
+		{"help", "help [command]", "show help for a command", helpCommand},
+	}
+}
+
+// cli holds the streams a command reads and writes.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.main(os.Args[1:]))
+}
+
+func (c *cli) main(args []string) int {
+	if len(args) == 0 {
+		c.usage(c.stderr)
+		return exitUsage
+	}
+
+	if cmd := lookupCommand(args[0]); cmd != nil {
+		return cmd.run(c, args[1:])
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help":
+		c.usage(c.stdout)
+		return exitOK
+	}
+
+	// `wordbuilder file.wb` is short for `wordbuilder run file.wb`.
+	if !strings.HasPrefix(args[0], "-") {
+		return runCommand(c, args)
+	}
+
+	fmt.Fprintf(c.stderr, "wordbuilder: unknown command %q\n", args[0])
+	c.usage(c.stderr)
+	return exitUsage
+}
+
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (c *cli) usage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: wordbuilder <command> [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run `wordbuilder help <command>` for the flags of a command.")
+}
+
+// flagSet returns the flag set of cmd, printing its usage on stderr.
+func (c *cli) flagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: wordbuilder %s\n\n%s.\n", cmd.usage, strings.ToUpper(cmd.summary[:1])+cmd.summary[1:])
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseFlags parses args with fs and checks the number of positional
+// arguments left. It returns a non-negative exit code if the command should
+// stop.
+func (c *cli) parseFlags(fs *flag.FlagSet, args []string, min, max int) int {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if fs.NArg() < min || (max >= 0 && fs.NArg() > max) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	return -1
+}
+
+// parseFile reads and parses the file at path, reporting any parse errors on
+// stderr.
+func (c *cli) parseFile(path string) (*ast.Program, bool) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "wordbuilder: %s\n", err)
+		return nil, false
+	}
+
+	source := string(content)
+	p := parser.New(lexer.NewFile(path, source))
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		printParseErrors(c.stderr, p.Errors(), source)
+		return program, false
+	}
+
+	return program, true
+}
+
+// load parses and evaluates the file at path into env, sending what the
+// program prints to out. Errors are reported on stderr.
+func (c *cli) load(path string, env *object.Environment, out io.Writer) (object.Object, bool) {
+	program, ok := c.parseFile(path)
+	if !ok {
+		return nil, false
+	}
+
+	stdout := evaluator.Stdout
+	evaluator.Stdout = out
+	defer func() { evaluator.Stdout = stdout }()
+
+	evaluated := evaluator.EvalFile(path, program, env)
+	if err, ok := evaluated.(*object.Error); ok {
+		printRuntimeError(c.stderr, err)
+		return nil, false
+	}
+
+	return evaluated, true
+}
+
+// loadQuietly loads the file at path, discarding what the program prints, so
+// that commands reporting on its vocabulary only show their own output.
+func (c *cli) loadQuietly(path string) (*object.Environment, bool) {
+	env := object.NewEnvironment()
+	_, ok := c.load(path, env, ioutil.Discard)
+	return env, ok
+}
+
+func printParseErrors(out io.Writer, errors []parser.Error, source string) {
+	for _, msg := range errors {
+		io.WriteString(out, msg.Render(source)+"\n")
+	}
+}
+
+// printRuntimeError reports err with an excerpt of the file it was raised in,
+// which may be an imported one.
+func printRuntimeError(out io.Writer, err *object.Error) {
+	io.WriteString(out, err.Pos.String()+": "+err.Inspect()+"\n")
+	if source, readErr := ioutil.ReadFile(err.Pos.File); readErr == nil {
+		if excerpt := token.Excerpt(string(source), err.Pos); excerpt != "" {
+			io.WriteString(out, excerpt+"\n")
+		}
+	}
+	for _, frame := range err.Stack {
+		io.WriteString(out, "\tcalled from "+frame.String()+"\n")
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	c := &cli{stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+	code := c.main(args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.wb")
+	bad := filepath.Join(dir, "bad.wb")
+	failing := filepath.Join(dir, "failing.wb")
+
+	ioutil.WriteFile(good, []byte(`word: "boato" {"Ostentación."};
+ref: "Musil";
+puts("noise");
+wordcount();`), 0644)
+	ioutil.WriteFile(bad, []byte(`word: boato;`), 0644)
+	ioutil.WriteFile(failing, []byte(`missing;`), 0644)
+
+	tests := []struct {
+		args           []string
+		expectedCode   int
+		expectedStdout string
+	}{
+		{[]string{}, exitUsage, ""},
+		{[]string{"run", good}, exitOK, "noise\n1\n"},
+		{[]string{good}, exitOK, "noise\n1\n"},
+		{[]string{"run"}, exitUsage, ""},
+		{[]string{"run", bad}, exitError, ""},
+		{[]string{"run", failing}, exitError, ""},
+		{[]string{"check", good, bad}, exitError, ""},
+		{[]string{"check", good}, exitOK, ""},
+		{[]string{"search", "bo", good}, exitOK, "word: boato\n"},
+		{[]string{"search", "ostentación", good}, exitError, ""},
+		{[]string{"search", "-defs", "ostentación", good}, exitOK, "word: boato\n"},
+		{[]string{"export", "--format", "csv", good}, exitOK,
+			"kind,name,definition\nword,boato,Ostentación.\nref,Musil,\n"},
+		{[]string{"export", "-format", "yaml", good}, exitUsage, ""},
+		{[]string{"-nope"}, exitUsage, ""},
+	}
+
+	for _, tt := range tests {
+		code, stdout, stderr := runCLI(t, tt.args...)
+		if code != tt.expectedCode {
+			t.Errorf("%v: wrong exit code. expected=%d, got=%d (stderr %q)",
+				tt.args, tt.expectedCode, code, stderr)
+		}
+		if stdout != tt.expectedStdout {
+			t.Errorf("%v: wrong output. expected=%q, got=%q", tt.args, tt.expectedStdout, stdout)
+		}
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocab.wb")
+	ioutil.WriteFile(path, []byte(`word: "boato" {"Ostentación."};
+word: "súcubo";
+me: {"una idea"};`), 0644)
+
+	code, stdout, _ := runCLI(t, "stats", path)
+	if code != exitOK {
+		t.Fatalf("wrong exit code. got=%d", code)
+	}
+
+	for _, line := range []string{"words         2  (1 defined)", "thoughts      1", "files         1"} {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("stats output does not contain %q. got=\n%s", line, stdout)
+		}
+	}
+}
+
+func TestFmtCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messy.wb")
+	ioutil.WriteFile(path, []byte("word:\"boato\"   {\"Ostentación.\"};\n\n\nlet x=1+2 # sum\n"), 0600)
+
+	code, stdout, _ := runCLI(t, "fmt", "-l", path)
+	if code != exitError || stdout != path+"\n" {
+		t.Errorf("fmt -l: expected the file to be listed. got code=%d, stdout=%q", code, stdout)
+	}
+
+	if code, _, stderr := runCLI(t, "fmt", path); code != exitOK {
+		t.Fatalf("fmt: wrong exit code. got=%d (stderr %q)", code, stderr)
+	}
+
+	expected := "word: \"boato\" {\"Ostentación.\"};\n\nlet x = 1 + 2; # sum\n"
+	if content, _ := ioutil.ReadFile(path); string(content) != expected {
+		t.Errorf("wrong formatted file. expected=%q, got=%q", expected, content)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("fmt changed the permissions of the file. got=%v", info.Mode().Perm())
+	}
+
+	if code, stdout, _ := runCLI(t, "fmt", "-l", path); code != exitOK || stdout != "" {
+		t.Errorf("fmt -l after fmt: got code=%d, stdout=%q", code, stdout)
+	}
+}
@@ -1,52 +1,75 @@
-package object
-
-func NewEnvironment() *Environment {
-	s := make(map[string]Object)
-	return &Environment{store: s, thoughts: []string{}, quotes: make([]Quote, 0)}
-}
-
-func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
-}
-
-type Environment struct {
-	store    map[string]Object
-	thoughts []string
-	quotes   []Quote
-	outer    *Environment
-}
-
-func (e *Environment) Thoughts() []string {
-	return e.thoughts
-}
-
-func (e *Environment) AddThought(thought string) {
-	e.thoughts = append(e.thoughts, thought)
-}
-
-func (e *Environment) AddQuote(q Quote) {
-	e.quotes = append(e.quotes, q)
-}
-
-func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
-	if !ok && e.outer != nil {
-		obj, ok = e.outer.Get(name)
-	}
-	return obj, ok
-}
-
-func (e *Environment) Quotes() []Quote {
-	return e.quotes
-}
-
-func (e *Environment) Set(name string, val Object) Object {
-	e.store[name] = val
-	return val
-}
-
-func (e *Environment) Store() map[string]Object {
-	return e.store
-}
+package object
+
+func NewEnvironment() *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, knowledge: NewKnowledgeBase()}
+}
+
+// NewSharedEnvironment returns a top-level environment that records its
+// entries in an existing knowledge base. Imported files are evaluated in one.
+func NewSharedEnvironment(knowledge *KnowledgeBase) *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, knowledge: knowledge}
+}
+
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	s := make(map[string]Object)
+	return &Environment{store: s, knowledge: outer.knowledge, outer: outer}
+}
+
+// NewLoopEnvironment returns the scope of one iteration of a loop, holding
+// the loop variables vars. Other variables set in it are set in outer, as
+// in any other block.
+func NewLoopEnvironment(outer *Environment, vars map[string]Object) *Environment {
+	return &Environment{store: vars, knowledge: outer.knowledge, outer: outer, loop: true}
+}
+
+// Environment holds the lexical variables of a scope. Vocabulary entries live
+// in the KnowledgeBase shared by all the environments of a program.
+type Environment struct {
+	store     map[string]Object
+	knowledge *KnowledgeBase
+	outer     *Environment
+	loop      bool // set by NewLoopEnvironment
+}
+
+// Knowledge returns the program-wide knowledge base.
+func (e *Environment) Knowledge() *KnowledgeBase {
+	return e.knowledge
+}
+
+func (e *Environment) Thoughts() []string {
+	return e.knowledge.Thoughts()
+}
+
+func (e *Environment) AddThought(thought string) {
+	e.knowledge.AddThought(thought)
+}
+
+func (e *Environment) AddQuote(q Quote) {
+	e.knowledge.AddQuote(q)
+}
+
+func (e *Environment) Get(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	if !ok && e.outer != nil {
+		obj, ok = e.outer.Get(name)
+	}
+	return obj, ok
+}
+
+func (e *Environment) Quotes() []Quote {
+	return e.knowledge.Quotes()
+}
+
+func (e *Environment) Set(name string, val Object) Object {
+	if _, ok := e.store[name]; e.loop && !ok {
+		return e.outer.Set(name, val)
+	}
+	e.store[name] = val
+	return val
+}
+
+func (e *Environment) Store() map[string]Object {
+	return e.store
+}
@@ -0,0 +1,64 @@
+package object
+
+// Equal reports whether a and b hold the same value. Numbers are compared by
+// value whatever their type, arrays and hashes element by element, entries
+// by kind, name and definition, and quotes by author and text. Functions and
+// builtins are only equal to themselves.
+func Equal(a, b Object) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if IsNumber(a) && IsNumber(b) {
+		return equalNumbers(a, b)
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Null:
+		return true
+
+	case *Array:
+		b := b.(*Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !Equal(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+
+	case *Hash:
+		b := b.(*Hash)
+		if len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+		for key, pair := range a.Pairs {
+			other, ok := b.Pairs[key]
+			if !ok || !Equal(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+
+	case Entry:
+		b := b.(Entry)
+		return a.Name() == b.Name() && a.Text() == b.Text()
+
+	case *Quote:
+		b := b.(*Quote)
+		return a.By == b.By && a.Text == b.Text
+	}
+
+	return false
+}
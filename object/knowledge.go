@@ -0,0 +1,213 @@
+package object
+
+import (
+	"sort"
+	"strings"
+)
+
+// Entry is implemented by the named vocabulary objects kept in a
+// KnowledgeBase.
+type Entry interface {
+	Object
+	Name() string
+	Text() string // the definition, empty if the entry is not defined
+	Doc() string  // the comment above the entry in the source, if any
+	Attributes() Attributes
+}
+
+// Attribute is one item of the metadata of an entry, e.g. lang: "es".
+type Attribute struct {
+	Key   string
+	Value Object
+}
+
+// Attributes are the metadata of an entry, in the order they were written.
+type Attributes []Attribute
+
+// Get returns the value of the attribute key.
+func (attrs Attributes) Get(key string) (Object, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Hash returns the attributes as a hash from key to value.
+func (attrs Attributes) Hash() *Hash {
+	pairs := make(map[HashKey]HashPair, len(attrs))
+	for _, attr := range attrs {
+		key := &String{Value: attr.Key}
+		pairs[key.HashKey()] = HashPair{Key: key, Value: attr.Value}
+	}
+	return &Hash{Pairs: pairs}
+}
+
+// Order selects how KnowledgeBase.Entries sorts its result.
+type Order int
+
+const (
+	// DefinitionOrder lists entries in the order they were first defined.
+	DefinitionOrder Order = iota
+	// AlphabeticalOrder lists entries by name, ignoring case.
+	AlphabeticalOrder
+	// KindOrder lists words, references, concepts and translations, each
+	// group in definition order.
+	KindOrder
+)
+
+var orders = map[string]Order{
+	"definition": DefinitionOrder,
+	"alpha":      AlphabeticalOrder,
+	"kind":       KindOrder,
+}
+
+// LookupOrder maps the names accepted by the listing builtins ("definition",
+// "alpha" and "kind") to an Order.
+func LookupOrder(name string) (Order, bool) {
+	order, ok := orders[name]
+	return order, ok
+}
+
+var kindRank = map[Type]int{
+	WordObj:        0,
+	ReferenceObj:   1,
+	ConceptObj:     2,
+	TranslationObj: 3,
+}
+
+// KnowledgeBase holds the vocabulary of a program: words, references,
+// concepts and translations, plus thoughts and quotes. It is shared by every
+// environment of a program, so entries defined inside a block or a function
+// body are recorded globally and never mix with `let` bindings.
+type KnowledgeBase struct {
+	entries  map[string]Entry
+	order    []string
+	thoughts []string
+	quotes   []Quote
+
+	files   []string        // source files, in the order they were loaded
+	loaded  map[string]bool // false while a file is being evaluated
+	loading []string        // files being evaluated, outermost first
+}
+
+// NewKnowledgeBase ...
+func NewKnowledgeBase() *KnowledgeBase {
+	return &KnowledgeBase{
+		entries:  make(map[string]Entry),
+		thoughts: []string{},
+		quotes:   make([]Quote, 0),
+		loaded:   make(map[string]bool),
+	}
+}
+
+// Define records an entry, replacing any previous entry with the same name.
+// A redefined entry keeps the position of its first definition.
+func (kb *KnowledgeBase) Define(entry Entry) Entry {
+	name := entry.Name()
+	if _, ok := kb.entries[name]; !ok {
+		kb.order = append(kb.order, name)
+	}
+	kb.entries[name] = entry
+	return entry
+}
+
+// Lookup ...
+func (kb *KnowledgeBase) Lookup(name string) (Entry, bool) {
+	entry, ok := kb.entries[name]
+	return entry, ok
+}
+
+// Entries returns every named entry sorted by order. The result is the same
+// on every run for the same program.
+func (kb *KnowledgeBase) Entries(order Order) []Entry {
+	entries := make([]Entry, 0, len(kb.order))
+	for _, name := range kb.order {
+		entries = append(entries, kb.entries[name])
+	}
+
+	switch order {
+	case AlphabeticalOrder:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
+		})
+	case KindOrder:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return kindRank[entries[i].Type()] < kindRank[entries[j].Type()]
+		})
+	}
+
+	return entries
+}
+
+// Len returns the number of named entries.
+func (kb *KnowledgeBase) Len() int {
+	return len(kb.entries)
+}
+
+func (kb *KnowledgeBase) AddThought(thought string) {
+	kb.thoughts = append(kb.thoughts, thought)
+}
+
+func (kb *KnowledgeBase) Thoughts() []string {
+	return kb.thoughts
+}
+
+func (kb *KnowledgeBase) AddQuote(q Quote) {
+	kb.quotes = append(kb.quotes, q)
+}
+
+func (kb *KnowledgeBase) Quotes() []Quote {
+	return kb.quotes
+}
+
+// StartFile records that the source file at path is being evaluated into the
+// knowledge base.
+func (kb *KnowledgeBase) StartFile(path string) {
+	kb.files = append(kb.files, path)
+	kb.loaded[path] = false
+	kb.loading = append(kb.loading, path)
+}
+
+// FinishFile records that path has been evaluated.
+func (kb *KnowledgeBase) FinishFile(path string) {
+	kb.loaded[path] = true
+	kb.loading = removeLast(kb.loading, path)
+}
+
+// AbortFile forgets that path was started, after its evaluation failed, so
+// that it can be loaded again.
+func (kb *KnowledgeBase) AbortFile(path string) {
+	delete(kb.loaded, path)
+	kb.loading = removeLast(kb.loading, path)
+	kb.files = removeLast(kb.files, path)
+}
+
+// removeLast removes the last occurrence of path from paths.
+func removeLast(paths []string, path string) []string {
+	for i := len(paths) - 1; i >= 0; i-- {
+		if paths[i] == path {
+			return append(paths[:i], paths[i+1:]...)
+		}
+	}
+	return paths
+}
+
+// FileState reports whether path has been started and whether it has been
+// evaluated to the end. A file that is started but not done is still being
+// loaded by an enclosing import.
+func (kb *KnowledgeBase) FileState(path string) (started, done bool) {
+	done, started = kb.loaded[path]
+	return started, done
+}
+
+// Loading returns the files being evaluated, outermost first.
+func (kb *KnowledgeBase) Loading() []string {
+	return append([]string(nil), kb.loading...)
+}
+
+// Files returns every source file loaded so far, in load order.
+func (kb *KnowledgeBase) Files() []string {
+	return kb.files
+}
@@ -0,0 +1,42 @@
+package object
+
+import (
+	"testing"
+)
+
+func TestKnowledgeBaseEntriesOrder(t *testing.T) {
+	kb := NewKnowledgeBase()
+	kb.Define(&Word{Word: "vodevil"})
+	kb.Define(&Reference{Ref: "Musil"})
+	kb.Define(&Word{Word: "boato"})
+	kb.Define(&Concept{Concept: "Jena"})
+	kb.Define(&Translation{Translation: "snore"})
+	kb.Define(&Word{Word: "vodevil", Definition: "redefined"})
+
+	tests := []struct {
+		order    Order
+		expected []string
+	}{
+		{DefinitionOrder, []string{"vodevil", "Musil", "boato", "Jena", "snore"}},
+		{AlphabeticalOrder, []string{"boato", "Jena", "Musil", "snore", "vodevil"}},
+		{KindOrder, []string{"vodevil", "boato", "Musil", "Jena", "snore"}},
+	}
+
+	for _, tt := range tests {
+		entries := kb.Entries(tt.order)
+		if len(entries) != len(tt.expected) {
+			t.Fatalf("wrong number of entries. got=%d, want=%d", len(entries), len(tt.expected))
+		}
+
+		for i, name := range tt.expected {
+			if entries[i].Name() != name {
+				t.Errorf("order %d: entries[%d] wrong. got=%q, want=%q", tt.order, i, entries[i].Name(), name)
+			}
+		}
+	}
+
+	w, _ := kb.Lookup("vodevil")
+	if w.(*Word).Definition != "redefined" {
+		t.Errorf("redefinition not stored. got=%q", w.(*Word).Definition)
+	}
+}
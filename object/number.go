@@ -0,0 +1,135 @@
+package object
+
+import (
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// BigInteger holds an integer that does not fit in an int64. Arithmetic on
+// integers moves to a BigInteger when it overflows, and back when the result
+// fits again, so a BigInteger never holds a value an Integer could.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (bi *BigInteger) Type() Type      { return BigIntegerObj }
+func (bi *BigInteger) Inspect() string { return bi.Value.String() }
+
+func (bi *BigInteger) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(bi.Value.String()))
+	return HashKey{Type: bi.Type(), Value: h.Sum64()}
+}
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() Type { return FloatObj }
+
+// Inspect writes the float without an exponent and always with a fraction,
+// e.g. 2.0, so that it reads back as a float.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'f', -1, 64)
+	if strings.ContainsAny(s, ".IN") {
+		return s
+	}
+	return s + ".0"
+}
+
+// HashKey of a whole float is the key of the equal integer, so that 1.0 and
+// 1 are the same hash key.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && !math.IsInf(f.Value, 0) {
+		n, _ := big.NewFloat(f.Value).Int(nil)
+		return NewInteger(n).(Hashable).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// NewInteger returns n as an Integer if it fits in an int64, or else as a
+// BigInteger.
+func NewInteger(n *big.Int) Object {
+	if n.IsInt64() {
+		return &Integer{Value: n.Int64()}
+	}
+	return &BigInteger{Value: n}
+}
+
+// IsNumber reports whether obj is an Integer, a BigInteger or a Float.
+func IsNumber(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *BigInteger, *Float:
+		return true
+	}
+	return false
+}
+
+// ToFloat converts a number to a float64, rounding big integers.
+func ToFloat(obj Object) float64 {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value)
+	case *BigInteger:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *Float:
+		return obj.Value
+	}
+	return math.NaN()
+}
+
+// ToBig converts an Integer or a BigInteger to a big.Int.
+func ToBig(obj Object) *big.Int {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value)
+	case *BigInteger:
+		return obj.Value
+	}
+	return nil
+}
+
+// CompareNumbers compares two numbers exactly, without rounding big integers
+// to floats, and returns -1, 0 or +1. NaN is greater than any other number.
+func CompareNumbers(a, b Object) int {
+	an, bn := isNaN(a), isNaN(b)
+	switch {
+	case an && bn:
+		return 0
+	case an:
+		return 1
+	case bn:
+		return -1
+	}
+
+	_, af := a.(*Float)
+	_, bf := b.(*Float)
+	if af || bf {
+		return toBigFloat(a).Cmp(toBigFloat(b))
+	}
+	return ToBig(a).Cmp(ToBig(b))
+}
+
+func isNaN(obj Object) bool {
+	f, ok := obj.(*Float)
+	return ok && math.IsNaN(f.Value)
+}
+
+// toBigFloat converts a number other than NaN to a big.Float without
+// rounding.
+func toBigFloat(obj Object) *big.Float {
+	if f, ok := obj.(*Float); ok {
+		return big.NewFloat(f.Value)
+	}
+	return new(big.Float).SetInt(ToBig(obj))
+}
+
+// equalNumbers compares numbers exactly, so that equal numbers have the same
+// hash key. NaN is not equal to anything.
+func equalNumbers(a, b Object) bool {
+	return !isNaN(a) && !isNaN(b) && CompareNumbers(a, b) == 0
+}
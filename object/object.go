@@ -1,312 +1,489 @@
-package object
-
-import (
-	"bytes"
-	"fmt"
-	"hash/fnv"
-	"strconv"
-	"strings"
-	"wordbuilder/ast"
-)
-
-type Type string
-
-const (
-	IntegerObj     = "INTEGER"
-	BooleanObj     = "BOOLEAN"
-	NullObj        = "NULL"
-	ReturnValueObj = "RETURN_VALUE"
-	ErrorObj       = "ERROR"
-	FunctionObj    = "FUNCTION"
-	StringObj      = "STRING"
-	BuiltinObj     = "BUILTIN"
-	ArrayObj       = "ARRAY"
-	HashObj        = "HASH"
-	WordObj        = "WORD"
-	ReferenceObj   = "REF"
-	ConceptObj     = "CPT"
-	TranslationObj = "TR"
-	MeThoughtObj   = "ME"
-	QuoteObj       = "QUOTE"
-)
-
-type Object interface {
-	Type() Type
-	Inspect() string
-}
-
-type Integer struct {
-	Value int64
-}
-
-func (i *Integer) Inspect() string {
-	return fmt.Sprintf("%d", i.Value)
-}
-
-func (i *Integer) Type() Type {
-	return IntegerObj
-}
-
-type Boolean struct {
-	Value bool
-}
-
-func (b *Boolean) Inspect() string {
-	return fmt.Sprintf("%t", b.Value)
-}
-
-func (b *Boolean) Type() Type {
-	return BooleanObj
-}
-
-type Null struct{}
-
-func (n *Null) Type() Type {
-	return NullObj
-}
-
-func (n *Null) Inspect() string {
-	return "null"
-}
-
-type ReturnValue struct {
-	Value Object
-}
-
-func (rv *ReturnValue) Type() Type {
-	return ReturnValueObj
-}
-
-func (rv *ReturnValue) Inspect() string {
-	return rv.Value.Inspect()
-}
-
-type Error struct {
-	Message string
-}
-
-func (e *Error) Type() Type {
-	return ErrorObj
-}
-
-func (e *Error) Inspect() string {
-	return "ERROR: " + e.Message
-}
-
-type Function struct {
-	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
-}
-
-func (f *Function) Type() Type {
-	return FunctionObj
-}
-
-func (f *Function) Inspect() string {
-	var out bytes.Buffer
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
-
-	out.WriteString("fn")
-	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") ")
-	out.WriteString(f.Body.String())
-
-	return out.String()
-}
-
-type String struct {
-	Value string
-}
-
-func (s *String) Type() Type {
-	return StringObj
-}
-
-func (s *String) Inspect() string {
-	return s.Value
-}
-
-type BuiltinFunction func(env *Environment, args ...Object) Object
-
-type Builtin struct {
-	Fn BuiltinFunction
-}
-
-func (b *Builtin) Type() Type {
-	return BuiltinObj
-}
-
-func (b *Builtin) Inspect() string {
-	return "builtin function"
-}
-
-type Array struct {
-	Elements []Object
-}
-
-func (ao *Array) Type() Type { return ArrayObj }
-func (ao *Array) Inspect() string {
-	var out bytes.Buffer
-
-	elements := []string{}
-	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
-}
-
-type HashKey struct {
-	Type  Type
-	Value uint64
-}
-
-func (b *Boolean) HashKey() HashKey {
-	var value uint64
-	if b.Value {
-		value = 1
-	} else {
-		value = 0
-	}
-
-	return HashKey{Type: b.Type(), Value: value}
-}
-
-func (i *Integer) HashKey() HashKey {
-	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
-}
-
-func (s *String) HashKey() HashKey {
-	h := fnv.New64a()
-	h.Write([]byte(s.Value))
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
-}
-
-func (w *Word) HashKey() HashKey {
-	h := fnv.New64a()
-	h.Write([]byte(w.Word))
-	i, _ := strconv.ParseUint(w.Definition, 10, 64)
-	return HashKey{Type: w.Type(), Value: i}
-}
-
-type HashPair struct {
-	Key   Object
-	Value Object
-}
-
-type Hash struct {
-	Pairs map[HashKey]HashPair
-}
-
-func (h *HashPair) Type() Type {
-	return HashObj
-}
-
-func (h *Hash) Inspect() string {
-	var out bytes.Buffer
-
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
-	}
-
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
-
-	return out.String()
-}
-
-type Hashable interface {
-	HashKey() HashKey
-}
-
-func (h *Hash) Type() Type {
-	return HashObj
-}
-
-type Word struct {
-	Word       string
-	Definition string
-}
-
-func (w *Word) Type() Type {
-	return WordObj
-}
-
-func (w *Word) Inspect() string {
-	return fmt.Sprintf("%s->{%s}", w.Word, w.Definition)
-}
-
-type Quote struct {
-	By   string
-	Text string
-}
-
-func (q *Quote) Type() Type {
-	return WordObj
-}
-
-func (q *Quote) Inspect() string {
-	return fmt.Sprintf("\"%s\" - %s", q.Text, q.By)
-}
-
-type Reference struct {
-	Ref        string
-	Definition string
-}
-
-func (ref *Reference) Type() Type {
-	return ReferenceObj
-}
-
-func (ref *Reference) Inspect() string {
-	return fmt.Sprintf("%s->{%s}", ref.Ref, ref.Definition)
-}
-
-type Concept struct {
-	Concept    string
-	Definition string
-}
-
-func (cpt *Concept) Type() Type {
-	return ConceptObj
-}
-
-func (cpt *Concept) Inspect() string {
-	return fmt.Sprintf("%s->{%s}", cpt.Concept, cpt.Definition)
-}
-
-type Translation struct {
-	Translation string
-	Definition  string
-}
-
-func (tr *Translation) Type() Type {
-	return TranslationObj
-}
-
-func (tr *Translation) Inspect() string {
-	return fmt.Sprintf("%s->{%s}", tr.Translation, tr.Definition)
-}
-
-type MeThought struct {
-	Thought string
-}
-
-func (me *MeThought) Type() Type {
-	return MeThoughtObj
-}
-
-func (me *MeThought) Inspect() string {
-	return fmt.Sprintf("'%s'", me.Thought)
-}
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"wordbuilder/ast"
+	"wordbuilder/token"
+)
+
+type Type string
+
+const (
+	IntegerObj     = "INTEGER"
+	BigIntegerObj  = "BIG_INTEGER"
+	FloatObj       = "FLOAT"
+	BooleanObj     = "BOOLEAN"
+	NullObj        = "NULL"
+	ReturnValueObj = "RETURN_VALUE"
+	ErrorObj       = "ERROR"
+	FunctionObj    = "FUNCTION"
+	StringObj      = "STRING"
+	BuiltinObj     = "BUILTIN"
+	ArrayObj       = "ARRAY"
+	HashObj        = "HASH"
+	WordObj        = "WORD"
+	ReferenceObj   = "REF"
+	ConceptObj     = "CPT"
+	TranslationObj = "TR"
+	MeThoughtObj   = "ME"
+	QuoteObj       = "QUOTE"
+	BreakObj       = "BREAK"
+	ContinueObj    = "CONTINUE"
+)
+
+type Object interface {
+	Type() Type
+	Inspect() string
+}
+
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Inspect() string {
+	return fmt.Sprintf("%d", i.Value)
+}
+
+func (i *Integer) Type() Type {
+	return IntegerObj
+}
+
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Inspect() string {
+	return fmt.Sprintf("%t", b.Value)
+}
+
+func (b *Boolean) Type() Type {
+	return BooleanObj
+}
+
+type Null struct{}
+
+func (n *Null) Type() Type {
+	return NullObj
+}
+
+func (n *Null) Inspect() string {
+	return "null"
+}
+
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() Type {
+	return ReturnValueObj
+}
+
+func (rv *ReturnValue) Inspect() string {
+	return rv.Value.Inspect()
+}
+
+// Break and Continue leave the statements of a loop body early.
+type Break struct{}
+
+func (b *Break) Type() Type      { return BreakObj }
+func (b *Break) Inspect() string { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() Type      { return ContinueObj }
+func (c *Continue) Inspect() string { return "continue" }
+
+type Error struct {
+	Message string
+	Pos     token.Position // where the error was raised, if known
+	Stack   []Frame        // function calls active when it was raised, innermost first
+}
+
+// Frame is a call to a user function on the way to a runtime error.
+type Frame struct {
+	Function string         // name the function was bound to with `let`, if any
+	Pos      token.Position // position of the call
+}
+
+func (f Frame) String() string {
+	name := f.Function
+	if name == "" {
+		name = "fn"
+	}
+	return fmt.Sprintf("%s (%s)", name, f.Pos)
+}
+
+func (e *Error) Type() Type {
+	return ErrorObj
+}
+
+func (e *Error) Inspect() string {
+	return "ERROR: " + e.Message
+}
+
+type Function struct {
+	Name       string
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (f *Function) Type() Type {
+	return FunctionObj
+}
+
+func (f *Function) Inspect() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fn")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(f.Body.String())
+
+	return out.String()
+}
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() Type {
+	return StringObj
+}
+
+func (s *String) Inspect() string {
+	return s.Value
+}
+
+type BuiltinFunction func(env *Environment, args ...Object) Object
+
+type Builtin struct {
+	Fn BuiltinFunction
+}
+
+func (b *Builtin) Type() Type {
+	return BuiltinObj
+}
+
+func (b *Builtin) Inspect() string {
+	return "builtin function"
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (ao *Array) Type() Type { return ArrayObj }
+func (ao *Array) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range ao.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+type HashKey struct {
+	Type  Type
+	Value uint64
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+	if b.Value {
+		value = 1
+	} else {
+		value = 0
+	}
+
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+func (w *Word) HashKey() HashKey         { return entryHashKey(w) }
+func (ref *Reference) HashKey() HashKey  { return entryHashKey(ref) }
+func (cpt *Concept) HashKey() HashKey    { return entryHashKey(cpt) }
+func (tr *Translation) HashKey() HashKey { return entryHashKey(tr) }
+
+// entryHashKey hashes the name and definition of an entry, which is what
+// Equal compares.
+func entryHashKey(entry Entry) HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(entry.Name()))
+	h.Write([]byte{0})
+	h.Write([]byte(entry.Text()))
+	return HashKey{Type: entry.Type(), Value: h.Sum64()}
+}
+
+// HashKey hashes the author and text of the quote, which is what Equal
+// compares.
+func (q *Quote) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(q.By))
+	h.Write([]byte{0})
+	h.Write([]byte(q.Text))
+	return HashKey{Type: q.Type(), Value: h.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+type Hash struct {
+	Pairs map[HashKey]HashPair
+}
+
+func (h *HashPair) Type() Type {
+	return HashObj
+}
+
+func (h *Hash) Inspect() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	}
+	// Map iteration order is random; sort so the output is stable.
+	sort.Strings(pairs)
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// SortedPairs returns the pairs of the hash ordered by key: booleans,
+// numbers of every kind, strings and then entries, each by value. Keys of any
+// other type come last, by type and then by Inspect.
+func (h *Hash) SortedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i].Key, pairs[j].Key
+		if ra, rb := keyRank(a), keyRank(b); ra != rb {
+			return ra < rb
+		}
+		if IsNumber(a) && IsNumber(b) {
+			if cmp := CompareNumbers(a, b); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		if a.Type() != b.Type() {
+			return a.Type() < b.Type()
+		}
+		switch a := a.(type) {
+		case *Boolean:
+			return !a.Value && b.(*Boolean).Value
+		}
+		return a.Inspect() < b.Inspect()
+	})
+	return pairs
+}
+
+// keyRanks orders the types of hash keys in SortedPairs.
+var keyRanks = map[Type]int{
+	BooleanObj:     0,
+	IntegerObj:     1,
+	BigIntegerObj:  1,
+	FloatObj:       1,
+	StringObj:      2,
+	WordObj:        3,
+	ReferenceObj:   4,
+	ConceptObj:     5,
+	TranslationObj: 6,
+	QuoteObj:       7,
+}
+
+func keyRank(key Object) int {
+	if rank, ok := keyRanks[key.Type()]; ok {
+		return rank
+	}
+	return len(keyRanks)
+}
+
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (h *Hash) Type() Type {
+	return HashObj
+}
+
+type Word struct {
+	Word       string
+	Definition string
+	Comment    string
+	Metadata   Attributes
+}
+
+func (w *Word) Type() Type {
+	return WordObj
+}
+
+func (w *Word) Name() string {
+	return w.Word
+}
+
+func (w *Word) Text() string {
+	return w.Definition
+}
+
+func (w *Word) Doc() string {
+	return w.Comment
+}
+
+func (w *Word) Attributes() Attributes {
+	return w.Metadata
+}
+
+func (w *Word) Inspect() string {
+	return fmt.Sprintf("%s->{%s}", w.Word, w.Definition)
+}
+
+type Quote struct {
+	By         string
+	Text       string
+	Attributes Attributes
+}
+
+func (q *Quote) Type() Type {
+	return QuoteObj
+}
+
+func (q *Quote) Inspect() string {
+	return fmt.Sprintf("\"%s\" - %s", q.Text, q.By)
+}
+
+type Reference struct {
+	Ref        string
+	Definition string
+	Comment    string
+	Metadata   Attributes
+}
+
+func (ref *Reference) Type() Type {
+	return ReferenceObj
+}
+
+func (ref *Reference) Name() string {
+	return ref.Ref
+}
+
+func (ref *Reference) Text() string {
+	return ref.Definition
+}
+
+func (ref *Reference) Doc() string {
+	return ref.Comment
+}
+
+func (ref *Reference) Attributes() Attributes {
+	return ref.Metadata
+}
+
+func (ref *Reference) Inspect() string {
+	return fmt.Sprintf("%s->{%s}", ref.Ref, ref.Definition)
+}
+
+type Concept struct {
+	Concept    string
+	Definition string
+	Comment    string
+	Metadata   Attributes
+}
+
+func (cpt *Concept) Type() Type {
+	return ConceptObj
+}
+
+func (cpt *Concept) Name() string {
+	return cpt.Concept
+}
+
+func (cpt *Concept) Text() string {
+	return cpt.Definition
+}
+
+func (cpt *Concept) Doc() string {
+	return cpt.Comment
+}
+
+func (cpt *Concept) Attributes() Attributes {
+	return cpt.Metadata
+}
+
+func (cpt *Concept) Inspect() string {
+	return fmt.Sprintf("%s->{%s}", cpt.Concept, cpt.Definition)
+}
+
+type Translation struct {
+	Translation string
+	Definition  string
+	Comment     string
+	Metadata    Attributes
+}
+
+func (tr *Translation) Type() Type {
+	return TranslationObj
+}
+
+func (tr *Translation) Name() string {
+	return tr.Translation
+}
+
+func (tr *Translation) Text() string {
+	return tr.Definition
+}
+
+func (tr *Translation) Doc() string {
+	return tr.Comment
+}
+
+func (tr *Translation) Attributes() Attributes {
+	return tr.Metadata
+}
+
+func (tr *Translation) Inspect() string {
+	return fmt.Sprintf("%s->{%s}", tr.Translation, tr.Definition)
+}
+
+type MeThought struct {
+	Thought string
+}
+
+func (me *MeThought) Type() Type {
+	return MeThoughtObj
+}
+
+func (me *MeThought) Inspect() string {
+	return fmt.Sprintf("'%s'", me.Thought)
+}
@@ -1,25 +1,184 @@
-package object
-
-import (
-	"testing"
-)
-
-func TestStringHashKey(t *testing.T) {
-	hello1 := &String{Value: "Hello World"}
-	hello2 := &String{Value: "Hello World"}
-	diff1 := &String{Value: "My name is johnny"}
-	diff2 := &String{Value: "My name is johnny"}
-
-	if hello1.HashKey() != hello2.HashKey() {
-		t.Errorf("strings with same content have different hash keys")
-	}
-
-	if diff1.HashKey() != diff2.HashKey() {
-		t.Errorf("strings with same content have different hash keys")
-	}
-
-	if hello1.HashKey() == diff1.HashKey() {
-		t.Errorf("strings with different content have same hash keys")
-	}
-
-}
+package object
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestStringHashKey(t *testing.T) {
+	hello1 := &String{Value: "Hello World"}
+	hello2 := &String{Value: "Hello World"}
+	diff1 := &String{Value: "My name is johnny"}
+	diff2 := &String{Value: "My name is johnny"}
+
+	if hello1.HashKey() != hello2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if diff1.HashKey() != diff2.HashKey() {
+		t.Errorf("strings with same content have different hash keys")
+	}
+
+	if hello1.HashKey() == diff1.HashKey() {
+		t.Errorf("strings with different content have same hash keys")
+	}
+
+}
+
+func TestNumberHashKey(t *testing.T) {
+	one := &Integer{Value: 1}
+
+	if (&Float{Value: 1}).HashKey() != one.HashKey() {
+		t.Errorf("1.0 and 1 have different hash keys")
+	}
+	if (&Float{Value: 1.5}).HashKey() == one.HashKey() {
+		t.Errorf("1.5 and 1 have the same hash key")
+	}
+
+	big1, _ := new(big.Int).SetString("18446744073709551616", 10)
+	big2, _ := new(big.Int).SetString("-18446744073709551616", 10)
+	if (&BigInteger{Value: big1}).HashKey() == (&BigInteger{Value: big2}).HashKey() {
+		t.Errorf("a big integer and its negation have the same hash key")
+	}
+	if (&Float{Value: 18446744073709551616}).HashKey() != (&BigInteger{Value: big1}).HashKey() {
+		t.Errorf("a whole float and the equal big integer have different hash keys")
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{1.5, "1.5"},
+		{2, "2.0"},
+		{-0.25, "-0.25"},
+		{1e21, "1000000000000000000000.0"},
+		{math.Inf(1), "+Inf"},
+	}
+
+	for _, tt := range tests {
+		if got := (&Float{Value: tt.value}).Inspect(); got != tt.expected {
+			t.Errorf("Inspect of %g wrong. expected=%q, got=%q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestEntryHashKey(t *testing.T) {
+	boato1 := &Word{Word: "boato", Definition: "Ostentación."}
+	boato2 := &Word{Word: "boato", Definition: "Ostentación.", Comment: "doc"}
+	other := &Word{Word: "boato", Definition: "Pompa."}
+	ref := &Reference{Ref: "boato", Definition: "Ostentación."}
+	split := &Word{Word: "boatoOst", Definition: "entación."}
+
+	if boato1.HashKey() != boato2.HashKey() {
+		t.Errorf("equal words have different hash keys")
+	}
+
+	for _, entry := range []Hashable{other, ref, split} {
+		if boato1.HashKey() == entry.HashKey() {
+			t.Errorf("%s has the same hash key as %s", entry.(Object).Inspect(), boato1.Inspect())
+		}
+	}
+}
+
+func TestQuoteHashKey(t *testing.T) {
+	han1 := &Quote{By: "Han", Text: "Some text"}
+	han2 := &Quote{By: "Han", Text: "Some text", Attributes: Attributes{{Key: "lang", Value: &String{Value: "ko"}}}}
+	other := &Quote{By: "Han", Text: "Other text"}
+	split := &Quote{By: "HanSome", Text: " text"}
+
+	if han1.HashKey() != han2.HashKey() {
+		t.Errorf("equal quotes have different hash keys")
+	}
+
+	for _, q := range []*Quote{other, split} {
+		if han1.HashKey() == q.HashKey() {
+			t.Errorf("%s has the same hash key as %s", q.Inspect(), han1.Inspect())
+		}
+	}
+	if han1.HashKey() == (&Word{Word: "Han", Definition: "Some text"}).HashKey() {
+		t.Errorf("a quote has the same hash key as a word")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	word := &Word{Word: "boato", Definition: "Ostentación."}
+	array := func(elements ...Object) *Array { return &Array{Elements: elements} }
+	str := func(s string) *String { return &String{Value: s} }
+	hash := func(key string, value Object) *Hash {
+		return &Hash{Pairs: map[HashKey]HashPair{str(key).HashKey(): {Key: str(key), Value: value}}}
+	}
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, str("1"), false},
+		{&Integer{Value: 1}, &Float{Value: 1}, true},
+		{&Float{Value: 0.5}, &Float{Value: 0.5}, true},
+		{&Float{Value: math.NaN()}, &Float{Value: math.NaN()}, false},
+		{&Integer{Value: 9007199254740993}, &Float{Value: 9007199254740992}, false},
+		{&BigInteger{Value: new(big.Int).Lsh(big.NewInt(1), 70)}, &Float{Value: 1 << 70}, true},
+		{&Null{}, &Null{}, true},
+		{array(str("a"), &Integer{Value: 1}), array(str("a"), &Integer{Value: 1}), true},
+		{array(str("a")), array(str("a"), str("b")), false},
+		{array(array(str("a"))), array(array(str("b"))), false},
+		{hash("k", array(str("v"))), hash("k", array(str("v"))), true},
+		{hash("k", str("v")), hash("k", str("w")), false},
+		{hash("k", str("v")), hash("j", str("v")), false},
+		{word, &Word{Word: "boato", Definition: "Ostentación.", Comment: "doc"}, true},
+		{word, &Word{Word: "boato"}, false},
+		{word, &Reference{Ref: "boato", Definition: "Ostentación."}, false},
+		{&Quote{By: "Han", Text: "t"}, &Quote{By: "Han", Text: "t"}, true},
+		{&Function{}, &Function{}, false},
+	}
+
+	for i, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("tests[%d]: Equal(%s, %s) = %t, want %t", i, tt.a.Inspect(), tt.b.Inspect(), got, tt.expected)
+		}
+	}
+}
+
+func TestSortedPairs(t *testing.T) {
+	keys := []Object{
+		&Translation{Translation: "Zeitgeist", Definition: "espíritu"},
+		&String{Value: "b"},
+		&Concept{Concept: "hybris"},
+		&Integer{Value: 10},
+		&Reference{Ref: "Musil"},
+		&Word{Word: "boato", Definition: "Pompa."},
+		&Boolean{Value: true},
+		&Word{Word: "arenga"},
+		&String{Value: "a"},
+		&Integer{Value: -2},
+		&Boolean{Value: false},
+		&Float{Value: 2.5},
+		&BigInteger{Value: new(big.Int).Lsh(big.NewInt(1), 70)},
+		&Float{Value: 1e19},
+		&BigInteger{Value: new(big.Int).Lsh(big.NewInt(1), 64)},
+		&Quote{By: "Han", Text: "text"},
+	}
+	expected := "false true -2 2.5 10 10000000000000000000.0 18446744073709551616 1180591620717411303424 a b arenga->{} boato->{Pompa.} Musil->{} hybris->{} Zeitgeist->{espíritu} \"text\" - Han"
+
+	// Map iteration order changes from run to run; the order of the pairs
+	// must not.
+	for i := 0; i < 20; i++ {
+		hash := &Hash{Pairs: map[HashKey]HashPair{}}
+		for _, key := range keys {
+			hash.Pairs[key.(Hashable).HashKey()] = HashPair{Key: key, Value: &Null{}}
+		}
+
+		var got []string
+		for _, pair := range hash.SortedPairs() {
+			got = append(got, pair.Key.Inspect())
+		}
+		if strings.Join(got, " ") != expected {
+			t.Fatalf("wrong order. expected=%q, got=%q", expected, strings.Join(got, " "))
+		}
+	}
+}
@@ -1,61 +1,162 @@
-package parser
-
-import (
-	"testing"
-	"wordbuilder/ast"
-	"wordbuilder/lexer"
-	"wordbuilder/token"
-)
-
-func TestString(t *testing.T) {
-	program := &ast.Program{
-		Statements: []ast.Statement{
-			&ast.LetStatement{
-				Token: token.Token{Type: token.Let, Literal: "let"},
-				Name: &ast.Identifier{
-					Token: token.Token{Type: token.Ident, Literal: "myVar"},
-					Value: "myVar",
-				},
-				Value: &ast.Identifier{
-					Token: token.Token{Type: token.Ident, Literal: "anotherVar"},
-					Value: "anotherVar",
-				},
-			},
-		},
-	}
-
-	if program.String() != "let myVar = anotherVar;" {
-		t.Errorf("program.String() wrong. got=%q", program.String())
-	}
-}
-
-func TestBooleanExpression(t *testing.T) {
-	input := "true;"
-
-	l := lexer.New(input)
-	p := New(l)
-	program := p.ParseProgram()
-	checkParserErrors(t, p)
-
-	if len(program.Statements) != 1 {
-		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
-	}
-
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
-	}
-
-	ident, ok := stmt.Expression.(*ast.Boolean)
-	if !ok {
-		t.Fatalf("exp not *ast.Boolean. got=%T", stmt.Expression)
-	}
-
-	if ident.Value != true {
-		t.Errorf("ident.Value not %t. got=%t", true, ident.Value)
-	}
-
-	if ident.TokenLiteral() != "true" {
-		t.Errorf("ident.TokenLiteral not %s. got=%s", "true", ident.TokenLiteral())
-	}
-}
+package parser
+
+import (
+	"testing"
+	"wordbuilder/ast"
+	"wordbuilder/lexer"
+	"wordbuilder/token"
+)
+
+func TestString(t *testing.T) {
+	program := &ast.Program{
+		Statements: []ast.Statement{
+			&ast.LetStatement{
+				Token: token.Token{Type: token.Let, Literal: "let"},
+				Name: &ast.Identifier{
+					Token: token.Token{Type: token.Ident, Literal: "myVar"},
+					Value: "myVar",
+				},
+				Value: &ast.Identifier{
+					Token: token.Token{Type: token.Ident, Literal: "anotherVar"},
+					Value: "anotherVar",
+				},
+			},
+		},
+	}
+
+	if program.String() != "let myVar = anotherVar;" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestBooleanExpression(t *testing.T) {
+	input := "true;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+
+	ident, ok := stmt.Expression.(*ast.Boolean)
+	if !ok {
+		t.Fatalf("exp not *ast.Boolean. got=%T", stmt.Expression)
+	}
+
+	if ident.Value != true {
+		t.Errorf("ident.Value not %t. got=%t", true, ident.Value)
+	}
+
+	if ident.TokenLiteral() != "true" {
+		t.Errorf("ident.TokenLiteral not %s. got=%s", "true", ident.TokenLiteral())
+	}
+}
+
+func TestCommentMap(t *testing.T) {
+	input := `# file header
+
+# leading a
+let a = 1; # trailing a
+let f = fn() { # opens
+	# leading b
+	b;
+	# end of block
+};
+# end of file`
+
+	program := New(lexer.New(input)).ParseProgram()
+	let := program.Statements[0]
+	fn := program.Statements[1]
+	body := fn.(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body
+
+	tests := []struct {
+		node     ast.Node
+		leading  []string
+		trailing []string
+	}{
+		{let, []string{"# file header", "# leading a"}, []string{"# trailing a"}},
+		{body.Statements[0], []string{"# opens", "# leading b"}, nil},
+		{body, nil, []string{"# end of block"}},
+		{program, nil, []string{"# end of file"}},
+	}
+
+	for i, tt := range tests {
+		group := program.Comments[tt.node]
+		if group == nil {
+			t.Errorf("tests[%d] - no comments attached to %T", i, tt.node)
+			continue
+		}
+		if got := literals(group.Leading); !equal(got, tt.leading) {
+			t.Errorf("tests[%d] - wrong leading comments. expected=%q, got=%q", i, tt.leading, got)
+		}
+		if got := literals(group.Trailing); !equal(got, tt.trailing) {
+			t.Errorf("tests[%d] - wrong trailing comments. expected=%q, got=%q", i, tt.trailing, got)
+		}
+	}
+
+	if program.Comments[fn] != nil {
+		t.Errorf("comments attached to the statement holding the block: %+v", program.Comments[fn])
+	}
+	if doc := program.Comments.Doc(let); doc != "leading a" {
+		t.Errorf("wrong doc. expected=%q, got=%q", "leading a", doc)
+	}
+}
+
+func TestEntryDoc(t *testing.T) {
+	input := `#  Indented
+# second line
+word: "boato";
+# separated
+
+ref: "Musil";
+if (true) {
+	# nested
+	cpt: "hybris";
+}`
+
+	program := New(lexer.New(input)).ParseProgram()
+	nested := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.IfExpression).Consequence
+
+	tests := []struct {
+		doc      string
+		expected string
+	}{
+		{program.Statements[0].(*ast.WordStatement).Doc, " Indented\nsecond line"},
+		{program.Statements[1].(*ast.ReferenceStatement).Doc, ""},
+		{nested.Statements[0].(*ast.ConceptStatement).Doc, "nested"},
+	}
+
+	for i, tt := range tests {
+		if tt.doc != tt.expected {
+			t.Errorf("tests[%d] - wrong doc. expected=%q, got=%q", i, tt.expected, tt.doc)
+		}
+	}
+}
+
+func literals(comments []token.Token) []string {
+	var out []string
+	for _, c := range comments {
+		out = append(out, c.Literal)
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,807 +1,1251 @@
-package parser
-
-import (
-	"fmt"
-	"strconv"
-	"wordbuilder/ast"
-	"wordbuilder/lexer"
-	"wordbuilder/token"
-)
-
-const (
-	_ int = iota
-	// LOWEST ...
-	LOWEST
-	// EQUALS ...
-	EQUALS // ==
-	// LESSGREATER ...
-	LESSGREATER // > or <
-	// SUM ...
-	SUM // +
-	// PRODUCT ...
-	PRODUCT // *
-	// PREFIX ...
-	PREFIX // -X or !X
-	// CALL ...
-	CALL // myFunction(X)
-	// INDEX ...
-	INDEX
-)
-
-var precedences = map[token.Type]int{
-	token.Eq:          EQUALS,
-	token.NotEq:       EQUALS,
-	token.Lt:          LESSGREATER,
-	token.Gt:          LESSGREATER,
-	token.Plus:        SUM,
-	token.Minus:       SUM,
-	token.Slash:       PRODUCT,
-	token.Asterisk:    PRODUCT,
-	token.LeftParen:   CALL,
-	token.LeftBracket: INDEX,
-}
-
-func (p *Parser) peekPrecedence() int {
-	if p, ok := precedences[p.peekToken.Type]; ok {
-		return p
-	}
-
-	return LOWEST
-}
-
-func (p *Parser) curPrecedence() int {
-	if p, ok := precedences[p.curToken.Type]; ok {
-		return p
-	}
-	return LOWEST
-}
-
-type Error struct {
-	Error      string
-	LineNumber int
-}
-
-func (err *Error) String() string {
-	return fmt.Sprintf("Line %d:%s", err.LineNumber, err.Error)
-}
-
-// Parser ...
-type Parser struct {
-	l *lexer.Lexer
-
-	curToken  token.Token
-	peekToken token.Token
-	errors    []Error
-
-	prefixParseFns map[token.Type]prefixParseFn
-	infixParseFns  map[token.Type]infixParseFn
-}
-
-func (p *Parser) registerPrefix(tokenType token.Type, fn prefixParseFn) {
-	p.prefixParseFns[tokenType] = fn
-}
-
-func (p *Parser) registerInfix(tokenType token.Type, fn infixParseFn) {
-	p.infixParseFns[tokenType] = fn
-}
-
-// New ...
-func New(l *lexer.Lexer) *Parser {
-	p := &Parser{
-		l:      l,
-		errors: []Error{},
-	}
-
-	// Read two tokens, so curToken and peekToken are both set
-	p.nextToken()
-	p.nextToken()
-
-	p.prefixParseFns = make(map[token.Type]prefixParseFn)
-
-	p.registerPrefix(token.Ident, p.parseIdentifier)
-	p.registerPrefix(token.Int, p.parseIntegerLiteral)
-	p.registerPrefix(token.Bang, p.parsePrefixExpression)
-	p.registerPrefix(token.Minus, p.parsePrefixExpression)
-	p.registerPrefix(token.True, p.parseBoolean)
-	p.registerPrefix(token.False, p.parseBoolean)
-	p.registerPrefix(token.LeftParen, p.parseGroupedExpressions)
-	p.registerPrefix(token.If, p.parseIfExpression)
-	p.registerPrefix(token.Function, p.parseFunctionLiteral)
-	p.registerPrefix(token.String, p.parseStringLiteral)
-	p.registerPrefix(token.Word, p.parseStringLiteral)
-	p.registerPrefix(token.Me, p.parseStringLiteral)
-	p.registerPrefix(token.Tr, p.parseStringLiteral)
-	p.registerPrefix(token.Ref, p.parseStringLiteral)
-	p.registerPrefix(token.LeftBracket, p.parseArrayLiteral)
-	p.registerPrefix(token.LeftBrace, p.parseHashLiteral)
-	p.registerPrefix(token.Colon, p.parseStringLiteral)
-
-	p.infixParseFns = make(map[token.Type]infixParseFn)
-	p.registerInfix(token.Plus, p.parseInfixExpression)
-	p.registerInfix(token.Minus, p.parseInfixExpression)
-	p.registerInfix(token.Slash, p.parseInfixExpression)
-	p.registerInfix(token.Asterisk, p.parseInfixExpression)
-	p.registerInfix(token.Eq, p.parseInfixExpression)
-	p.registerInfix(token.NotEq, p.parseInfixExpression)
-	p.registerInfix(token.Lt, p.parseInfixExpression)
-	p.registerInfix(token.Gt, p.parseInfixExpression)
-	p.registerInfix(token.LeftParen, p.parseCallExpression)
-	p.registerInfix(token.LeftBracket, p.parseIndexExpression)
-
-	return p
-}
-
-func (p *Parser) parseHashLiteral() ast.Expression {
-	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
-
-	for !p.peekTokenIs(token.RightBrace) {
-		p.nextToken()
-		key := p.parseExpression(LOWEST)
-
-		if !p.expectPeek(token.Colon) {
-			return nil
-		}
-
-		p.nextToken()
-		value := p.parseExpression(LOWEST)
-
-		hash.Pairs[key] = value
-
-		if !p.peekTokenIs(token.RightBrace) && !p.expectPeek(token.Comma) {
-			return nil
-		}
-	}
-
-	if !p.expectPeek(token.RightBrace) {
-		return nil
-	}
-
-	return hash
-}
-
-func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
-
-	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
-
-	if !p.expectPeek(token.RightBracket) {
-		return nil
-	}
-
-	return exp
-}
-
-func (p *Parser) parseArrayLiteral() ast.Expression {
-	array := &ast.ArrayLiteral{Token: p.curToken}
-	array.Elements = p.parseExpressionList(token.RightBracket)
-	return array
-}
-
-func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
-	list := []ast.Expression{}
-
-	if p.peekTokenIs(end) {
-		p.nextToken()
-		return list
-	}
-
-	p.nextToken()
-	list = append(list, p.parseExpression(LOWEST))
-
-	for p.peekTokenIs(token.Comma) {
-		p.nextToken()
-		p.nextToken()
-		list = append(list, p.parseExpression(LOWEST))
-	}
-
-	if !p.expectPeek(end) {
-		return nil
-	}
-
-	return list
-}
-
-func (p *Parser) parseStringLiteral() ast.Expression {
-	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
-}
-
-func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
-	// exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	// exp.Arguments = p.parseExpressionList(token.RightParen)
-	// return exp
-
-	exp := &ast.CallExpression{
-		Token:    p.curToken,
-		Function: function,
-	}
-	exp.Arguments = p.parseExpressionList(token.RightParen)
-	return exp
-}
-
-func (p *Parser) parseCallArguments() []ast.Expression {
-	args := []ast.Expression{}
-
-	if p.peekTokenIs(token.RightParen) {
-		p.nextToken()
-		return args
-	}
-
-	p.nextToken()
-	args = append(args, p.parseExpression(LOWEST))
-
-	for p.peekTokenIs(token.Comma) {
-		p.nextToken()
-		p.nextToken()
-		args = append(args, p.parseExpression(LOWEST))
-	}
-
-	if !p.expectPeek(token.RightParen) {
-		return nil
-	}
-
-	return args
-}
-
-func (p *Parser) parseFunctionLiteral() ast.Expression {
-	lit := &ast.FunctionLiteral{Token: p.curToken}
-	if !p.expectPeek(token.LeftParen) {
-		return nil
-	}
-	lit.Parameters = p.parseFunctionParameters()
-	if !p.expectPeek(token.LeftBrace) {
-		return nil
-	}
-	lit.Body = p.parseBlockStatement()
-	return lit
-}
-
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
-
-	// no identifiers in the function signature.
-	if p.peekTokenIs(token.RightParen) {
-		p.nextToken()
-		return identifiers
-	}
-
-	p.nextToken()
-
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
-
-	for p.peekTokenIs(token.Comma) {
-		p.nextToken()
-		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
-	}
-
-	if !p.expectPeek(token.RightParen) {
-		return nil
-	}
-
-	return identifiers
-}
-
-func (p *Parser) parseIfExpression() ast.Expression {
-	expression := &ast.IfExpression{
-		Token: p.curToken,
-	}
-
-	if !p.expectPeek(token.LeftParen) {
-		return nil
-	}
-
-	p.nextToken()
-	expression.Condition = p.parseExpression(LOWEST)
-
-	if !p.expectPeek(token.RightParen) {
-		return nil
-	}
-
-	if !p.expectPeek(token.LeftBrace) {
-		return nil
-	}
-
-	expression.Consequence = p.parseBlockStatement()
-
-	if p.peekTokenIs(token.Else) {
-		p.nextToken()
-
-		if !p.expectPeek(token.LeftBrace) {
-			return nil
-		}
-
-		expression.Alternative = p.parseBlockStatement()
-	}
-
-	return expression
-
-}
-
-func (p *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{
-		Token: p.curToken,
-	}
-	block.Statements = []ast.Statement{}
-
-	p.nextToken()
-
-	for !p.curTokenIs(token.RightBrace) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
-		}
-		p.nextToken()
-	}
-
-	return block
-}
-
-func (p *Parser) parseGroupedExpressions() ast.Expression {
-	p.nextToken()
-	exp := p.parseExpression(LOWEST)
-	if !p.expectPeek(token.RightParen) {
-		return nil
-	}
-	return exp
-}
-
-func (p *Parser) parseBoolean() ast.Expression {
-	return &ast.Boolean{
-		Token: p.curToken,
-		Value: p.curTokenIs(token.True),
-	}
-}
-
-func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	expression := &ast.InfixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
-		Left:     left,
-	}
-
-	precedence := p.curPrecedence()
-	p.nextToken()
-	expression.Right = p.parseExpression(precedence)
-
-	return expression
-}
-
-func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
-	}
-}
-
-func (p *Parser) parsePrefixExpression() ast.Expression {
-	expression := &ast.PrefixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
-	}
-
-	p.nextToken()
-
-	expression.Right = p.parseExpression(PREFIX)
-
-	return expression
-}
-
-// Errors ...
-func (p *Parser) Errors() []Error {
-	return p.errors
-}
-
-func (p *Parser) peekError(t token.Type) {
-	msg := fmt.Sprintf("expected next token to be [%s], got %s instead",
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, Error{Error: msg, LineNumber: p.l.CurrentLine()})
-}
-
-func (p *Parser) nextToken() {
-	p.curToken = p.peekToken
-	p.peekToken = p.l.NextToken()
-}
-
-// ParseProgram ...
-func (p *Parser) ParseProgram() *ast.Program {
-	program := &ast.Program{}
-	program.Statements = []ast.Statement{}
-
-	for p.curToken.Type != token.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
-		}
-		p.nextToken()
-	}
-
-	return program
-}
-
-func (p *Parser) parseStatement() ast.Statement {
-
-	switch p.curToken.Type {
-	case token.Let:
-		return p.parseLetStatement()
-	case token.Return:
-		return p.parseReturnStatement()
-	case token.Word:
-		return p.parseWordStatement()
-	case token.Ref:
-		return p.parseReferenceStatement()
-	case token.Cpt:
-		return p.parseConceptStatement()
-	case token.Tr:
-		return p.parseTranslationStatement()
-	case token.Me:
-		return p.parseMeThoughtStatement()
-	case token.Quote:
-		return p.parseQuoteStatement()
-	default:
-		return p.parseExpressionStatement()
-	}
-}
-
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{Token: p.curToken}
-
-	stmt.Expression = p.parseExpression(LOWEST)
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
-	stmt := &ast.ReturnStatement{Token: p.curToken}
-
-	p.nextToken()
-
-	stmt.ReturnValue = p.parseExpression(LOWEST)
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	// TODO: We're skipping the expressions until we
-	// encounter a semicolon
-	// for !p.curTokenIs(token.SEMICOLON) {
-	// 	p.nextToken()
-	// }
-
-	return stmt
-}
-
-func (p *Parser) debug() {
-	fmt.Printf("[debug]: curToken is: %q\n", p.curToken)
-	fmt.Printf("[debug]: peekToken is: %q\n", p.peekToken)
-}
-
-func (p *Parser) parseWordStatement() *ast.WordStatement {
-
-	stmt := &ast.WordStatement{Token: p.curToken}
-
-	if !p.expectPeek(token.Colon) {
-		return nil
-	}
-
-	// Expecting an identifier after the :
-	if !p.peekTokenIs(token.String) {
-		return nil
-	}
-
-	p.nextToken()
-
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if p.peekTokenIs(token.LeftBrace) {
-		p.nextToken()
-
-		if !p.expectPeek(token.String) {
-			return nil
-		}
-
-		stmt.Definition = p.curToken.Literal
-		stmt.Value = p.parseExpression(LOWEST)
-
-		if !p.expectPeek(token.RightBrace) {
-			return nil
-		}
-		stmt.Defined = true
-	}
-
-	p.nextToken()
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) parseTranslationStatement() *ast.TranslationStatement {
-	stmt := &ast.TranslationStatement{Token: p.curToken}
-
-	if !p.expectPeek(token.Colon) {
-		return nil
-	}
-
-	// Expecting an identifier after the :
-	if !p.peekTokenIs(token.Ident) {
-		return nil
-	}
-
-	p.nextToken()
-
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if p.peekTokenIs(token.LeftBrace) {
-		p.nextToken()
-
-		if !p.expectPeek(token.String) {
-			return nil
-		}
-
-		stmt.Definition = p.curToken.Literal
-		stmt.Value = p.parseExpression(LOWEST)
-
-		if !p.expectPeek(token.RightBrace) {
-			return nil
-		}
-		stmt.Defined = true
-	}
-
-	p.nextToken()
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) parseQuoteStatement() *ast.QuoteStatement {
-
-	stmt := &ast.QuoteStatement{}
-
-	if !p.expectPeek(token.Colon) {
-		return nil
-	}
-
-	// Expecting an identifier after the :
-	if !p.peekTokenIs(token.String) {
-		return nil
-	}
-
-	p.nextToken()
-
-	stmt.By = p.curToken.Literal
-
-	if p.peekTokenIs(token.LeftBrace) {
-		p.nextToken()
-
-		if !p.expectPeek(token.String) {
-			return nil
-		}
-
-		stmt.Text = p.parseExpression(LOWEST).String()
-
-		if !p.expectPeek(token.RightBrace) {
-			return nil
-		}
-	}
-
-	p.nextToken()
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) parseMeThoughtStatement() *ast.MeThoughtStatement {
-	stmt := &ast.MeThoughtStatement{}
-
-	if !p.expectPeek(token.Colon) {
-		return nil
-	}
-
-	// Expecting an identifier after the :
-	if !p.expectPeek(token.LeftBrace) {
-		return nil
-	}
-
-	if p.peekTokenIs(token.String) {
-		p.nextToken()
-		stmt.Content = p.parseExpression(LOWEST).String()
-		if !p.expectPeek(token.RightBrace) {
-			return nil
-		}
-	}
-
-	p.nextToken()
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) parseReferenceStatement() *ast.ReferenceStatement {
-	stmt := &ast.ReferenceStatement{Token: p.curToken}
-
-	if !p.expectPeek(token.Colon) {
-		return nil
-	}
-
-	// Expecting an identifier after the :
-	if !p.peekTokenIs(token.String) {
-		return nil
-	}
-
-	p.nextToken()
-
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if p.peekTokenIs(token.LeftBrace) {
-		p.nextToken()
-
-		if !p.expectPeek(token.String) {
-			return nil
-		}
-
-		stmt.Definition = p.curToken.Literal
-		stmt.Value = p.parseExpression(LOWEST)
-
-		if !p.expectPeek(token.RightBrace) {
-			return nil
-		}
-		stmt.Defined = true
-	}
-
-	p.nextToken()
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) parseConceptStatement() *ast.ConceptStatement {
-	stmt := &ast.ConceptStatement{Token: p.curToken}
-
-	if !p.expectPeek(token.Colon) {
-		return nil
-	}
-
-	// Expecting an identifier after the :
-	if !p.peekTokenIs(token.String) {
-		return nil
-	}
-
-	p.nextToken()
-
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if p.peekTokenIs(token.LeftBrace) {
-		p.nextToken()
-
-		if !p.expectPeek(token.String) {
-			return nil
-		}
-
-		stmt.Definition = p.curToken.Literal
-		stmt.Value = p.parseExpression(LOWEST)
-
-		if !p.expectPeek(token.RightBrace) {
-			return nil
-		}
-		stmt.Defined = true
-	}
-
-	p.nextToken()
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-
-}
-
-func (p *Parser) parseLetStatement() *ast.LetStatement {
-	stmt := &ast.LetStatement{Token: p.curToken}
-
-	if !p.expectPeek(token.Ident) {
-		return nil
-	}
-
-	stmt.Name = &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
-	}
-
-	if !p.expectPeek(token.Assign) {
-		return nil
-	}
-
-	p.nextToken()
-
-	stmt.Value = p.parseExpression(LOWEST)
-
-	if p.peekTokenIs(token.Semicolon) {
-		p.nextToken()
-	}
-
-	return stmt
-}
-
-func (p *Parser) curTokenIs(t token.Type) bool {
-	return p.curToken.Type == t
-}
-
-func (p *Parser) peekTokenIs(t token.Type) bool {
-	return p.peekToken.Type == t
-}
-
-func (p *Parser) expectPeek(t token.Type) bool {
-	if p.peekTokenIs(t) {
-		p.nextToken()
-		return true
-	}
-	p.peekError(t)
-	return false
-}
-
-type (
-	prefixParseFn func() ast.Expression
-	infixParseFn  func(ast.Expression) ast.Expression
-)
-
-func (p *Parser) parseIntegerLiteral() ast.Expression {
-	lit := &ast.IntegerLiteral{Token: p.curToken}
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
-	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as Integer", p.curToken.Literal)
-		p.errors = append(p.errors, Error{Error: msg, LineNumber: p.l.CurrentLine()})
-		return nil
-	}
-	lit.Value = value
-	return lit
-}
-
-func (p *Parser) noPrefixParseFnError(t token.Type) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, Error{Error: msg, LineNumber: p.l.CurrentLine()})
-}
-
-func (p *Parser) parseExpression(precedence int) ast.Expression {
-	prefix := p.prefixParseFns[p.curToken.Type]
-	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
-		return nil
-	}
-
-	leftExp := prefix()
-
-	for !p.peekTokenIs(token.Semicolon) && precedence < p.peekPrecedence() {
-		infix := p.infixParseFns[p.peekToken.Type]
-		if infix == nil {
-			return leftExp
-		}
-
-		p.nextToken()
-		leftExp = infix(leftExp)
-	}
-
-	return leftExp
-}
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"wordbuilder/ast"
+	"wordbuilder/lexer"
+	"wordbuilder/token"
+)
+
+const (
+	_ int = iota
+	// LOWEST ...
+	LOWEST
+	// OR ...
+	OR // ||
+	// AND ...
+	AND // &&
+	// EQUALS ...
+	EQUALS // ==
+	// LESSGREATER ...
+	LESSGREATER // > or <
+	// SUM ...
+	SUM // +
+	// PRODUCT ...
+	PRODUCT // *
+	// PREFIX ...
+	PREFIX // -X or !X
+	// CALL ...
+	CALL // myFunction(X)
+	// INDEX ...
+	INDEX
+)
+
+var precedences = map[token.Type]int{
+	token.Or:          OR,
+	token.And:         AND,
+	token.Eq:          EQUALS,
+	token.NotEq:       EQUALS,
+	token.Lt:          LESSGREATER,
+	token.Gt:          LESSGREATER,
+	token.LtEq:        LESSGREATER,
+	token.GtEq:        LESSGREATER,
+	token.Plus:        SUM,
+	token.Minus:       SUM,
+	token.Slash:       PRODUCT,
+	token.Asterisk:    PRODUCT,
+	token.Percent:     PRODUCT,
+	token.LeftParen:   CALL,
+	token.LeftBracket: INDEX,
+	token.Dot:         INDEX,
+}
+
+// Precedence returns how tightly the infix operator t binds, or LOWEST if t
+// is not an infix operator.
+func Precedence(t token.Type) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
+func (p *Parser) peekPrecedence() int {
+	if p, ok := precedences[p.peekToken.Type]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
+func (p *Parser) curPrecedence() int {
+	if p, ok := precedences[p.curToken.Type]; ok {
+		return p
+	}
+	return LOWEST
+}
+
+type Error struct {
+	Error string
+	Pos   token.Position
+}
+
+func (err *Error) String() string {
+	return fmt.Sprintf("%s: %s", err.Pos, err.Error)
+}
+
+// Render formats the error followed by the offending line of source and a
+// caret under the column where the problem was found.
+func (err *Error) Render(source string) string {
+	excerpt := token.Excerpt(source, err.Pos)
+	if excerpt == "" {
+		return err.String()
+	}
+	return err.String() + "\n" + excerpt
+}
+
+// Parser ...
+type Parser struct {
+	l *lexer.Lexer
+
+	curToken   token.Token
+	peekToken  token.Token
+	errors     []Error
+	blockDepth int  // number of blocks being parsed, used to resync after errors
+	loopDepth  int  // number of loops around the current statement in this function
+	truncated  bool // an unterminated string took the rest of the input
+
+	prefixParseFns map[token.Type]prefixParseFn
+	infixParseFns  map[token.Type]infixParseFn
+}
+
+func (p *Parser) registerPrefix(tokenType token.Type, fn prefixParseFn) {
+	p.prefixParseFns[tokenType] = fn
+}
+
+func (p *Parser) registerInfix(tokenType token.Type, fn infixParseFn) {
+	p.infixParseFns[tokenType] = fn
+}
+
+// New ...
+func New(l *lexer.Lexer) *Parser {
+	p := &Parser{
+		l:      l,
+		errors: []Error{},
+	}
+
+	// Read two tokens, so curToken and peekToken are both set
+	p.nextToken()
+	p.nextToken()
+
+	p.prefixParseFns = make(map[token.Type]prefixParseFn)
+
+	p.registerPrefix(token.Ident, p.parseIdentifier)
+	p.registerPrefix(token.Int, p.parseIntegerLiteral)
+	p.registerPrefix(token.Float, p.parseFloatLiteral)
+	p.registerPrefix(token.Bang, p.parsePrefixExpression)
+	p.registerPrefix(token.Minus, p.parsePrefixExpression)
+	p.registerPrefix(token.True, p.parseBoolean)
+	p.registerPrefix(token.False, p.parseBoolean)
+	p.registerPrefix(token.LeftParen, p.parseGroupedExpressions)
+	p.registerPrefix(token.If, p.parseIfExpression)
+	p.registerPrefix(token.Function, p.parseFunctionLiteral)
+	p.registerPrefix(token.String, p.parseStringLiteral)
+	p.registerPrefix(token.StringHead, p.parseInterpolatedString)
+	p.registerPrefix(token.Illegal, p.parseIllegal)
+	p.registerPrefix(token.BadEscape, p.parseIllegal)
+	p.registerPrefix(token.Word, p.parseStringLiteral)
+	p.registerPrefix(token.Me, p.parseStringLiteral)
+	p.registerPrefix(token.Tr, p.parseStringLiteral)
+	p.registerPrefix(token.Ref, p.parseStringLiteral)
+	p.registerPrefix(token.LeftBracket, p.parseArrayLiteral)
+	p.registerPrefix(token.LeftBrace, p.parseHashLiteral)
+	p.registerPrefix(token.Colon, p.parseStringLiteral)
+
+	p.infixParseFns = make(map[token.Type]infixParseFn)
+	p.registerInfix(token.Plus, p.parseInfixExpression)
+	p.registerInfix(token.Minus, p.parseInfixExpression)
+	p.registerInfix(token.Slash, p.parseInfixExpression)
+	p.registerInfix(token.Asterisk, p.parseInfixExpression)
+	p.registerInfix(token.Eq, p.parseInfixExpression)
+	p.registerInfix(token.NotEq, p.parseInfixExpression)
+	p.registerInfix(token.Lt, p.parseInfixExpression)
+	p.registerInfix(token.Gt, p.parseInfixExpression)
+	p.registerInfix(token.LtEq, p.parseInfixExpression)
+	p.registerInfix(token.GtEq, p.parseInfixExpression)
+	p.registerInfix(token.Percent, p.parseInfixExpression)
+	p.registerInfix(token.And, p.parseInfixExpression)
+	p.registerInfix(token.Or, p.parseInfixExpression)
+	p.registerInfix(token.LeftParen, p.parseCallExpression)
+	p.registerInfix(token.LeftBracket, p.parseIndexExpression)
+	p.registerInfix(token.Dot, p.parseFieldExpression)
+
+	return p
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken}
+	hash.Pairs = make(map[ast.Expression]ast.Expression)
+
+	for !p.peekTokenIs(token.RightBrace) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Pairs[key] = value
+
+		if !p.peekTokenIs(token.RightBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RightBrace) {
+		return nil
+	}
+	hash.Rbrace = p.curToken.Pos
+
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RightBracket) {
+		return nil
+	}
+	exp.Rbrack = p.curToken.Pos
+
+	return exp
+}
+
+func (p *Parser) parseFieldExpression(left ast.Expression) ast.Expression {
+	exp := &ast.FieldExpression{Token: p.curToken, Left: left}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	exp.Field = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressionList(token.RightBracket)
+	if p.curTokenIs(token.RightBracket) {
+		array.Rbrack = p.curToken.Pos
+	}
+	return array
+}
+
+func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return list
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// parseInterpolatedString parses a string with embedded expressions, from
+// its STRING_HEAD token to its STRING_TAIL.
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = append(str.Parts, p.parseStringLiteral())
+
+	for !p.curTokenIs(token.StringTail) {
+		if p.peekTokenIs(token.StringMiddle) || p.peekTokenIs(token.StringTail) {
+			msg := "empty expression in string interpolation"
+			p.errors = append(p.errors, Error{Error: msg, Pos: p.peekToken.Pos})
+			return nil
+		}
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		if p.peekTokenIs(token.StringMiddle) {
+			p.nextToken()
+		} else if !p.expectPeek(token.StringTail) {
+			return nil
+		}
+		str.Parts = append(str.Parts, p.parseStringLiteral())
+	}
+
+	return str
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	// exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	// exp.Arguments = p.parseExpressionList(token.RightParen)
+	// return exp
+
+	exp := &ast.CallExpression{
+		Token:    p.curToken,
+		Function: function,
+	}
+	exp.Arguments = p.parseExpressionList(token.RightParen)
+	if p.curTokenIs(token.RightParen) {
+		exp.Rparen = p.curToken.Pos
+	}
+	return exp
+}
+
+func (p *Parser) parseCallArguments() []ast.Expression {
+	args := []ast.Expression{}
+
+	if p.peekTokenIs(token.RightParen) {
+		p.nextToken()
+		return args
+	}
+
+	p.nextToken()
+	args = append(args, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		p.nextToken()
+		args = append(args, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+
+	return args
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.expectPeek(token.LeftParen) {
+		return nil
+	}
+	lit.Parameters = p.parseFunctionParameters()
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	// A loop outside the function cannot be left from inside it.
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+	return lit
+}
+
+func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	// no identifiers in the function signature.
+	if p.peekTokenIs(token.RightParen) {
+		p.nextToken()
+		return identifiers
+	}
+
+	p.nextToken()
+
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	identifiers = append(identifiers, ident)
+
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		p.nextToken()
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		identifiers = append(identifiers, ident)
+	}
+
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+
+	return identifiers
+}
+
+func (p *Parser) parseIfExpression() ast.Expression {
+	expression := &ast.IfExpression{
+		Token: p.curToken,
+	}
+
+	if !p.expectPeek(token.LeftParen) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	expression.Consequence = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.Else) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LeftBrace) {
+			return nil
+		}
+
+		expression.Alternative = p.parseBlockStatement()
+	}
+
+	return expression
+
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{
+		Token: p.curToken,
+	}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+	p.blockDepth++
+
+	for !p.curTokenIs(token.RightBrace) && !p.curTokenIs(token.EOF) {
+		stmt := p.parseStatementOrSynchronize()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	p.blockDepth--
+
+	if p.curTokenIs(token.EOF) {
+		if p.truncated {
+			return block
+		}
+		msg := fmt.Sprintf("expected [%s] to close the block opened at %s, got EOF instead",
+			token.RightBrace, block.Token.Pos)
+		p.errors = append(p.errors, Error{Error: msg, Pos: p.curToken.Pos})
+		return block
+	}
+	block.Rbrace = p.curToken.Pos
+
+	return block
+}
+
+func (p *Parser) parseGroupedExpressions() ast.Expression {
+	p.nextToken()
+	exp := p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{
+		Token: p.curToken,
+		Value: p.curTokenIs(token.True),
+	}
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+func (p *Parser) parseIdentifier() ast.Expression {
+	return &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	expression := &ast.PrefixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+	}
+
+	p.nextToken()
+
+	expression.Right = p.parseExpression(PREFIX)
+
+	return expression
+}
+
+// Errors ...
+func (p *Parser) Errors() []Error {
+	return p.errors
+}
+
+func (p *Parser) peekError(t token.Type) {
+	if p.peekTokenIs(token.Illegal) || p.peekTokenIs(token.BadEscape) {
+		p.illegalError(p.peekToken)
+		return
+	}
+	if p.truncated {
+		return // the input ended early; the string has been reported
+	}
+
+	msg := fmt.Sprintf("expected next token to be [%s], got %s instead",
+		t, p.peekToken.Type)
+	p.errors = append(p.errors, Error{Error: msg, Pos: p.peekToken.Pos})
+}
+
+func (p *Parser) nextToken() {
+	p.curToken = p.peekToken
+	p.peekToken = p.l.NextToken()
+}
+
+// ParseProgram ...
+func (p *Parser) ParseProgram() *ast.Program {
+	program := &ast.Program{}
+	program.Statements = []ast.Statement{}
+
+	for p.curToken.Type != token.EOF {
+		stmt := p.parseStatementOrSynchronize()
+		if stmt != nil {
+			program.Statements = append(program.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	program.Comments = ast.NewCommentMap(program, p.l.Comments())
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.WordStatement:
+			node.Doc = program.Comments.Doc(node)
+		case *ast.ReferenceStatement:
+			node.Doc = program.Comments.Doc(node)
+		case *ast.ConceptStatement:
+			node.Doc = program.Comments.Doc(node)
+		case *ast.TranslationStatement:
+			node.Doc = program.Comments.Doc(node)
+		}
+		return true
+	})
+
+	return program
+}
+
+// parseStatementOrSynchronize parses one statement. When the statement has
+// errors it is dropped and the parser skips to the end of it, so parsing
+// can go on and report every problem in the input in a single run.
+func (p *Parser) parseStatementOrSynchronize() ast.Statement {
+	errorCount := len(p.errors)
+
+	stmt := p.parseStatement()
+	if len(p.errors) > errorCount {
+		p.synchronize()
+		return nil
+	}
+
+	return stmt
+}
+
+var statementKeywords = map[token.Type]bool{
+	token.Let:    true,
+	token.Return: true,
+	token.If:     true,
+	token.Word:   true,
+	token.Ref:    true,
+	token.Cpt:    true,
+	token.Tr:     true,
+	token.Me:     true,
+	token.Quote:  true,
+	token.Import: true,
+	token.For:    true,
+	token.While:  true,
+}
+
+// synchronize skips tokens until the current one is the ';' closing the
+// broken statement, or the next token starts a new statement. Inside a block
+// it also stops before the '}' that closes it.
+func (p *Parser) synchronize() {
+	for !p.curTokenIs(token.Semicolon) && !p.curTokenIs(token.EOF) {
+		if statementKeywords[p.peekToken.Type] || p.peekTokenIs(token.EOF) {
+			return
+		}
+		if p.blockDepth > 0 && p.peekTokenIs(token.RightBrace) {
+			return
+		}
+		p.nextToken()
+	}
+}
+
+// expectEntryName checks that the token after `word:`, `ref:`, ... is the
+// entry name and advances onto it.
+func (p *Parser) expectEntryName(keyword string, t token.Type) bool {
+	if p.peekTokenIs(t) {
+		p.nextToken()
+		return true
+	}
+	if p.peekTokenIs(token.Illegal) || p.peekTokenIs(token.BadEscape) {
+		p.illegalError(p.peekToken)
+		return false
+	}
+	if p.peekTokenIs(token.StringHead) {
+		p.interpolationError()
+		return false
+	}
+
+	msg := fmt.Sprintf("expected name after `%s:` to be [%s], got %s %q instead",
+		keyword, t, p.peekToken.Type, p.peekToken.Literal)
+	p.errors = append(p.errors, Error{Error: msg, Pos: p.peekToken.Pos})
+	return false
+}
+
+// expectEntryText moves to the string holding the text of an entry.
+func (p *Parser) expectEntryText() bool {
+	if p.peekTokenIs(token.StringHead) {
+		p.interpolationError()
+		return false
+	}
+	return p.expectPeek(token.String)
+}
+
+// interpolationError reports a string with embedded expressions where an
+// entry expects plain text: entries are data and are written back as they
+// were read.
+func (p *Parser) interpolationError() {
+	msg := "interpolation is not allowed in entries; write \\${ for a literal ${"
+	p.errors = append(p.errors, Error{Error: msg, Pos: p.peekToken.Pos})
+}
+
+// expectEntryEnd consumes the ';' closing an entry. It can be left out
+// before a '}' or at the end of the input.
+func (p *Parser) expectEntryEnd() bool {
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+		return true
+	}
+
+	if p.peekTokenIs(token.RightBrace) || p.peekTokenIs(token.EOF) {
+		return true
+	}
+
+	p.peekError(token.Semicolon)
+	return false
+}
+
+func (p *Parser) parseStatement() ast.Statement {
+
+	switch p.curToken.Type {
+	case token.Let:
+		return p.parseLetStatement()
+	case token.Return:
+		return p.parseReturnStatement()
+	case token.Word:
+		return p.parseWordStatement()
+	case token.Ref:
+		return p.parseReferenceStatement()
+	case token.Cpt:
+		return p.parseConceptStatement()
+	case token.Tr:
+		return p.parseTranslationStatement()
+	case token.Me:
+		return p.parseMeThoughtStatement()
+	case token.Quote:
+		return p.parseQuoteStatement()
+	case token.Import:
+		return p.parseImportStatement()
+	case token.For:
+		return p.parseForStatement()
+	case token.While:
+		return p.parseWhileStatement()
+	case token.Break, token.Continue:
+		return p.parseBranchStatement()
+	default:
+		return p.parseExpressionStatement()
+	}
+}
+
+func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+	stmt := &ast.ReturnStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+
+	// TODO: We're skipping the expressions until we
+	// encounter a semicolon
+	// for !p.curTokenIs(token.SEMICOLON) {
+	// 	p.nextToken()
+	// }
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LeftParen) {
+		return nil
+	}
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+	stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.In) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LeftParen) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RightParen) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseLoopBody parses the block of a loop and the optional ';' after it.
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+	return body
+}
+
+func (p *Parser) parseBranchStatement() ast.Statement {
+	stmt := &ast.BranchStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		msg := fmt.Sprintf("%s outside a loop", p.curToken.Literal)
+		p.errors = append(p.errors, Error{Error: msg, Pos: p.curToken.Pos})
+		return nil
+	}
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.String) {
+		return nil
+	}
+
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) debug() {
+	fmt.Printf("[debug]: curToken is: %q\n", p.curToken)
+	fmt.Printf("[debug]: peekToken is: %q\n", p.peekToken)
+}
+
+func (p *Parser) parseWordStatement() *ast.WordStatement {
+
+	stmt := &ast.WordStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	// Expecting the entry name after the :
+	if !p.expectEntryName(stmt.Token.Literal, token.String) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.LeftBracket) {
+		p.nextToken()
+		if stmt.Attributes = p.parseAttributeList(); stmt.Attributes == nil {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.LeftBrace) {
+		p.nextToken()
+
+		if !p.expectEntryText() {
+			return nil
+		}
+
+		stmt.Definition = p.curToken.Literal
+		stmt.Value = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RightBrace) {
+			return nil
+		}
+		stmt.Rbrace = p.curToken.Pos
+		stmt.Defined = true
+	}
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseAttributeList parses the `[key: value, ...]` metadata of an entry,
+// starting at the '['.
+func (p *Parser) parseAttributeList() *ast.AttributeList {
+	list := &ast.AttributeList{Token: p.curToken}
+	seen := map[string]bool{}
+
+	for !p.peekTokenIs(token.RightBracket) {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+		key := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if seen[key.Value] {
+			msg := fmt.Sprintf("duplicate attribute %q", key.Value)
+			p.errors = append(p.errors, Error{Error: msg, Pos: key.Pos()})
+			return nil
+		}
+		seen[key.Value] = true
+
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+		p.nextToken()
+		list.Attributes = append(list.Attributes, &ast.Attribute{Key: key, Value: p.parseExpression(LOWEST)})
+
+		if !p.peekTokenIs(token.RightBracket) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	list.Rbrack = p.curToken.Pos
+
+	return list
+}
+
+func (p *Parser) parseTranslationStatement() *ast.TranslationStatement {
+	stmt := &ast.TranslationStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	// Expecting the entry name after the :
+	if !p.expectEntryName(stmt.Token.Literal, token.Ident) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.LeftBracket) {
+		p.nextToken()
+		if stmt.Attributes = p.parseAttributeList(); stmt.Attributes == nil {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.LeftBrace) {
+		p.nextToken()
+
+		if !p.expectEntryText() {
+			return nil
+		}
+
+		stmt.Definition = p.curToken.Literal
+		stmt.Value = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RightBrace) {
+			return nil
+		}
+		stmt.Rbrace = p.curToken.Pos
+		stmt.Defined = true
+	}
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseQuoteStatement() *ast.QuoteStatement {
+
+	stmt := &ast.QuoteStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	// Expecting the entry name after the :
+	if !p.expectEntryName(stmt.Token.Literal, token.String) {
+		return nil
+	}
+
+	stmt.By = p.curToken.Literal
+
+	if p.peekTokenIs(token.LeftBracket) {
+		p.nextToken()
+		if stmt.Attributes = p.parseAttributeList(); stmt.Attributes == nil {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.LeftBrace) {
+		p.nextToken()
+
+		if !p.expectEntryText() {
+			return nil
+		}
+
+		stmt.Text = p.parseExpression(LOWEST).String()
+
+		if !p.expectPeek(token.RightBrace) {
+			return nil
+		}
+		stmt.Rbrace = p.curToken.Pos
+	}
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseMeThoughtStatement() *ast.MeThoughtStatement {
+	stmt := &ast.MeThoughtStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	// Expecting an identifier after the :
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.StringHead) {
+		p.interpolationError()
+		return nil
+	}
+	if p.peekTokenIs(token.String) {
+		p.nextToken()
+		stmt.Content = p.parseExpression(LOWEST).String()
+	}
+
+	if !p.expectPeek(token.RightBrace) {
+		return nil
+	}
+	stmt.Rbrace = p.curToken.Pos
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseReferenceStatement() *ast.ReferenceStatement {
+	stmt := &ast.ReferenceStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	// Expecting the entry name after the :
+	if !p.expectEntryName(stmt.Token.Literal, token.String) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.LeftBracket) {
+		p.nextToken()
+		if stmt.Attributes = p.parseAttributeList(); stmt.Attributes == nil {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.LeftBrace) {
+		p.nextToken()
+
+		if !p.expectEntryText() {
+			return nil
+		}
+
+		stmt.Definition = p.curToken.Literal
+		stmt.Value = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RightBrace) {
+			return nil
+		}
+		stmt.Rbrace = p.curToken.Pos
+		stmt.Defined = true
+	}
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseConceptStatement() *ast.ConceptStatement {
+	stmt := &ast.ConceptStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Colon) {
+		return nil
+	}
+
+	// Expecting the entry name after the :
+	if !p.expectEntryName(stmt.Token.Literal, token.String) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.LeftBracket) {
+		p.nextToken()
+		if stmt.Attributes = p.parseAttributeList(); stmt.Attributes == nil {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(token.LeftBrace) {
+		p.nextToken()
+
+		if !p.expectEntryText() {
+			return nil
+		}
+
+		stmt.Definition = p.curToken.Literal
+		stmt.Value = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RightBrace) {
+			return nil
+		}
+		stmt.Rbrace = p.curToken.Pos
+		stmt.Defined = true
+	}
+
+	if !p.expectEntryEnd() {
+		return nil
+	}
+
+	return stmt
+
+}
+
+func (p *Parser) parseLetStatement() *ast.LetStatement {
+	stmt := &ast.LetStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	if !p.expectPeek(token.Assign) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) curTokenIs(t token.Type) bool {
+	return p.curToken.Type == t
+}
+
+func (p *Parser) peekTokenIs(t token.Type) bool {
+	return p.peekToken.Type == t
+}
+
+func (p *Parser) expectPeek(t token.Type) bool {
+	if p.peekTokenIs(t) {
+		p.nextToken()
+		return true
+	}
+	p.peekError(t)
+	return false
+}
+
+type (
+	prefixParseFn func() ast.Expression
+	infixParseFn  func(ast.Expression) ast.Expression
+)
+
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+	lit := &ast.IntegerLiteral{Token: p.curToken}
+	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if err == nil {
+		lit.Value = value
+		return lit
+	}
+
+	if n, ok := new(big.Int).SetString(p.curToken.Literal, 0); ok {
+		lit.Big = n
+		return lit
+	}
+
+	msg := fmt.Sprintf("could not parse %q as Integer", p.curToken.Literal)
+	p.errors = append(p.errors, Error{Error: msg, Pos: p.curToken.Pos})
+	return nil
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as Float", p.curToken.Literal)
+		p.errors = append(p.errors, Error{Error: msg, Pos: p.curToken.Pos})
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseIllegal() ast.Expression {
+	p.illegalError(p.curToken)
+	return nil
+}
+
+// illegalError reports the lexical error behind an ILLEGAL or BAD_ESCAPE
+// token: an unterminated string, an invalid escape sequence or a stray
+// character.
+func (p *Parser) illegalError(tok token.Token) {
+	var msg string
+	switch {
+	case tok.Type == token.BadEscape:
+		msg = fmt.Sprintf("invalid escape sequence %s", tok.Literal)
+	case strings.HasPrefix(tok.Literal, `"`):
+		msg = "unterminated string"
+		p.truncated = true
+	default:
+		msg = fmt.Sprintf("illegal character %q", tok.Literal)
+	}
+	p.errors = append(p.errors, Error{Error: msg, Pos: tok.Pos})
+}
+
+func (p *Parser) noPrefixParseFnError(t token.Type) {
+	msg := fmt.Sprintf("no prefix parse function for %s found", t)
+	p.errors = append(p.errors, Error{Error: msg, Pos: p.curToken.Pos})
+}
+
+func (p *Parser) parseExpression(precedence int) ast.Expression {
+	prefix := p.prefixParseFns[p.curToken.Type]
+	if prefix == nil {
+		p.noPrefixParseFnError(p.curToken.Type)
+		return nil
+	}
+
+	leftExp := prefix()
+
+	for !p.peekTokenIs(token.Semicolon) && precedence < p.peekPrecedence() {
+		infix := p.infixParseFns[p.peekToken.Type]
+		if infix == nil {
+			return leftExp
+		}
+
+		p.nextToken()
+		leftExp = infix(leftExp)
+	}
+
+	return leftExp
+}
@@ -51,7 +51,7 @@ func checkParserErrors(t *testing.T, p *Parser) {
 
 	t.Errorf("parser has %d errors", len(errors))
 	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+		t.Errorf("parser error: %q", msg.String())
 	}
 	t.FailNow()
 }
@@ -241,6 +241,40 @@ return 993322;
 	}
 }
 
+func TestImportStatement(t *testing.T) {
+	input := `
+import "vocab/2666.wb";
+import "vocab"
+`
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{"vocab/2666.wb", "vocab"}
+	if len(program.Statements) != len(expected) {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d",
+			len(expected), len(program.Statements))
+	}
+
+	for i, stmt := range program.Statements {
+		importStmt, ok := stmt.(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ImportStatement. got=%T", stmt)
+		}
+		if importStmt.Path.Value != expected[i] {
+			t.Errorf("importStmt.Path.Value not %q. got=%q", expected[i], importStmt.Path.Value)
+		}
+	}
+
+	p = New(lexer.New(`import vocab;`))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected an error for an import without a path string")
+	}
+}
+
 func TestWordStatement(t *testing.T) {
 
 	tests := []struct {
@@ -545,6 +579,42 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "3.25;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 3.25 {
+		t.Errorf("literal.Value not %g. got=%g", 3.25, literal.Value)
+	}
+}
+
+func TestBigIntegerLiteralExpression(t *testing.T) {
+	input := "123456789012345678901234567890;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Big == nil || literal.Big.String() != "123456789012345678901234567890" {
+		t.Errorf("literal.Big wrong. got=%v", literal.Big)
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -773,6 +843,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a || b && c == d",
+			"(a || (b && (c == d)))",
+		},
+		{
+			"a && b || c",
+			"((a && b) || c)",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a % b + c * d % e",
+			"((a % b) + ((c * d) % e))",
+		},
+		{
+			"a.b.c",
+			"((a.b).c)",
+		},
+		{
+			"-q.by + h.list[0]",
+			"((-(q.by)) + ((h.list)[0]))",
+		},
+		{
+			"quotes()[0].text == x",
+			"(((quotes()[0]).text) == x)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1042,6 +1140,39 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedString(t *testing.T) {
+	input := `"word ${w.name} has ${len(w.definition) + 1} chars";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+
+	expected := []string{"word ", "(w.name)", " has ", "(len((w.definition)) + 1)", " chars"}
+	if len(str.Parts) != len(expected) {
+		t.Fatalf("wrong number of parts. expected=%d, got=%d", len(expected), len(str.Parts))
+	}
+	for i, part := range str.Parts {
+		if part.String() != expected[i] {
+			t.Errorf("parts[%d] wrong. expected=%q, got=%q", i, expected[i], part.String())
+		}
+	}
+
+	want := `"word ${(w.name)} has ${(len((w.definition)) + 1)} chars"`
+	if str.String() != want {
+		t.Errorf("str.String() wrong. expected=%q, got=%q", want, str.String())
+	}
+	if end := str.End(); end.Column != 52 {
+		t.Errorf("str.End() wrong. expected column 52, got=%d", end.Column)
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -1088,6 +1219,146 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingFieldExpressions(t *testing.T) {
+	input := "boato.definition"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	fieldExp, ok := stmt.Expression.(*ast.FieldExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.FieldExpression. got=%T", stmt.Expression)
+	}
+
+	if !testIdentifier(t, fieldExp.Left, "boato") {
+		return
+	}
+
+	if !testIdentifier(t, fieldExp.Field, "definition") {
+		return
+	}
+}
+
+func TestFieldExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"boato.;", "expected next token to be [IDENT], got ; instead"},
+		{"boato.1", "expected next token to be [IDENT], got INT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected a parser error", tt.input)
+			continue
+		}
+		if errors[0].Error != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error)
+		}
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		key      string
+		value    string
+		iterable string
+		body     string
+	}{
+		{"for (w in words()) { puts(w); }", "", "w", "words()", "puts(w)"},
+		{"for (k, v in h) { continue; };", "k", "v", "h", "continue;"},
+		{"for (x in [1, 2]) { if (x > 1) { break } }", "", "x", "[1, 2]", "if(x > 1) break;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: program has %d statements", tt.input, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("%q: stmt not *ast.ForStatement. got=%T", tt.input, program.Statements[0])
+		}
+
+		key := ""
+		if stmt.Key != nil {
+			key = stmt.Key.Value
+		}
+		if key != tt.key || stmt.Value.Value != tt.value {
+			t.Errorf("%q: wrong variables. got=%q, %q", tt.input, key, stmt.Value.Value)
+		}
+		if stmt.Iterable.String() != tt.iterable {
+			t.Errorf("%q: wrong iterable. got=%q", tt.input, stmt.Iterable.String())
+		}
+		if stmt.Body.String() != tt.body {
+			t.Errorf("%q: wrong body. got=%q", tt.input, stmt.Body.String())
+		}
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := "while (x < 10) { let x = x + 1; }"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", 10) {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body has %d statements, want 1", len(stmt.Body.Statements))
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "break outside a loop"},
+		{"if (true) { continue; }", "continue outside a loop"},
+		{"for (x in xs) { let f = fn() { break; }; }", "break outside a loop"},
+		{"for (x xs) { }", "expected next token to be [IN], got IDENT instead"},
+		{"for (1 in xs) { }", "expected next token to be [IDENT], got INT instead"},
+		{"while x { }", "expected next token to be [(], got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected a parser error", tt.input)
+			continue
+		}
+		if errors[0].Error != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].Error)
+		}
+	}
+}
+
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	input := `{"one": 1, "two": 2, "three": 3}`
 	l := lexer.New(input)
@@ -1184,3 +1455,257 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		testFunc(value)
 	}
 }
+
+func TestErrorPositions(t *testing.T) {
+	input := `word: "arenga" {"
+Quizá del occit.
+"};
+
+let x = 1 +;`
+
+	l := lexer.NewFile("a.wb", input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("wrong number of errors. got=%d", len(errors))
+	}
+
+	expected := "a.wb:5:12: no prefix parse function for ; found\n" +
+		"let x = 1 +;\n" +
+		"           ^"
+	if errors[0].Render(input) != expected {
+		t.Errorf("wrong rendered error. expected=%q, got=%q", expected, errors[0].Render(input))
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(x, y) {
+	x + y;
+};
+word: "boato" {"ostentación"};
+add(1, [2, 3][0]);`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		node          ast.Node
+		expectedStart string
+		expectedEnd   string
+	}{
+		{program.Statements[0], "1:1", "3:2"},
+		{program.Statements[1], "4:1", "4:30"},
+		{program.Statements[2], "5:1", "5:18"},
+		{program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1], "5:8", "5:17"},
+		{program, "1:1", "5:18"},
+	}
+
+	for i, tt := range tests {
+		if tt.node.Pos().String() != tt.expectedStart {
+			t.Errorf("tests[%d] - start wrong. expected=%s, got=%s", i, tt.expectedStart, tt.node.Pos())
+		}
+		if tt.node.End().String() != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%s, got=%s", i, tt.expectedEnd, tt.node.End())
+		}
+	}
+}
+
+func TestEntryStatementErrors(t *testing.T) {
+	input := `word: boato;
+word: "a" {boato};
+ref: "Ernst Jünger" "Ernst Jünger (Heidelberg, 1895)";
+let z = ;
+if (true) { cpt: 12 }
+word: "ok";
+tr: "str";
+quote: x {"t"};
+me: "thought";
+word: "last" {"fine"}`
+
+	expected := []string{
+		"1:7: expected name after `word:` to be [STRING], got IDENT \"boato\" instead",
+		"2:12: expected next token to be [STRING], got IDENT instead",
+		"3:21: expected next token to be [;], got STRING instead",
+		"4:9: no prefix parse function for ; found",
+		"5:18: expected name after `cpt:` to be [STRING], got INT \"12\" instead",
+		"7:5: expected name after `tr:` to be [IDENT], got STRING \"str\" instead",
+		"8:8: expected name after `quote:` to be [STRING], got IDENT \"x\" instead",
+		"9:5: expected next token to be [{], got STRING instead",
+	}
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != len(expected) {
+		for _, err := range errors {
+			t.Logf("parser error: %s", err.String())
+		}
+		t.Fatalf("wrong number of errors. got=%d, want=%d", len(errors), len(expected))
+	}
+
+	for i, msg := range expected {
+		if errors[i].String() != msg {
+			t.Errorf("errors[%d] wrong. expected=%q, got=%q", i, msg, errors[i].String())
+		}
+	}
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	testWordStatement(t, program.Statements[0], false, "", "ok")
+	testWordStatement(t, program.Statements[1], true, "fine", "last")
+}
+
+func TestEntryInterpolationErrors(t *testing.T) {
+	msg := "interpolation is not allowed in entries; write \\${ for a literal ${"
+	tests := []struct {
+		input string
+		pos   string
+	}{
+		{`word: "x ${n}";`, "1:7"},
+		{`word: "x" {"tiene ${n} sentidos"};`, "1:12"},
+		{`ref: "r" {"de ${n}"};`, "1:11"},
+		{`cpt: "c" {"de ${n}"};`, "1:11"},
+		{`tr: T {"de ${n}"};`, "1:8"},
+		{`quote: "By ${n}";`, "1:8"},
+		{`quote: "By" {"dijo ${n}"};`, "1:14"},
+		{`me: {"una ${n} idea"};`, "1:6"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input + "\nword: \"ok\";"))
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected one error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+		if want := tt.pos + ": " + msg; errors[0].String() != want {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, want, errors[0].String())
+		}
+		if len(program.Statements) != 1 {
+			t.Errorf("%q: expected parsing to go on after the error, got %d statements", tt.input, len(program.Statements))
+		}
+	}
+
+	p := New(lexer.New(`word: "x" {"tiene \${n} sentidos"};`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	testWordStatement(t, program.Statements[0], true, "tiene ${n} sentidos", "x")
+}
+
+func TestUnterminatedBlock(t *testing.T) {
+	input := `if (true) { word: "a";`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("wrong number of errors. got=%d", len(errors))
+	}
+
+	expected := "1:23: expected [}] to close the block opened at 1:11, got EOF instead"
+	if errors[0].String() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0].String())
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`word: "a" {"dijo \q"};`, `1:18: invalid escape sequence \q`},
+		{`puts("\u{zz}");`, `1:7: invalid escape sequence \u{z`},
+		{`word: "a" {"never closed};`, "1:12: unterminated string"},
+		{`word: "never closed};`, "1:7: unterminated string"},
+		{"let a = \"\"\"raw\n", "1:9: unterminated string"},
+		{`let a = 1 @ 2;`, `1:11: illegal character "@"`},
+		{`let a = 1 \ 2;`, `1:11: illegal character "\\"`},
+		{`word: \q;`, `1:7: illegal character "\\"`},
+		{"if (true) {\n\tputs(\"oops);\n", "2:7: unterminated string"},
+		{`let a = "a ${x} b;`, "1:9: unterminated string"},
+		{`let a = "a ${x b";`, "1:16: expected next token to be [STRING_TAIL], got IDENT instead"},
+		{`let a = "a ${} b";`, "1:14: empty expression in string interpolation"},
+		{`let a = "${x} \q";`, `1:15: invalid escape sequence \q`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected one error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].String() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].String())
+		}
+	}
+}
+
+func TestEntryAttributes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`word: "boato" [tags: ["latin", "2666"], lang: "es"] {"Ostentación."};`,
+			`word: "boato" [tags: [latin, 2666], lang: "es"] {"Ostentación."};`},
+		{`ref: "Musil" [added: "2024-03-01"];`, `ref: "Musil" [added: "2024-03-01"];`},
+		{`cpt: "hybris" [];`, `cpt: "hybris" [];`},
+		{`tr: Zeitgeist [lang: "de", source: "Bolaño",] {"espíritu"};`,
+			`tr: Zeitgeist [lang: "de", source: "Bolaño"] {"espíritu"};`},
+		{`quote: "Han" [lang: "ko"] {"text"};`, `quote: "Han" [lang: "ko"] {"text"};`},
+		{`quote: "Han" [lang: "ko"];`, `quote: "Han" [lang: "ko"];`},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Errorf("wrong statement. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestEntryAttributeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`word: "a" [lang: "es", lang: "en"];`, `1:24: duplicate attribute "lang"`},
+		{`word: "a" ["lang": "es"];`, "1:12: expected next token to be [IDENT], got STRING instead"},
+		{`word: "a" [lang "es"];`, "1:17: expected next token to be [:], got STRING instead"},
+		{`word: "a" [lang: "es" source: "b"];`, "1:23: expected next token to be [,], got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("%q: expected one error, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].String() != tt.expected {
+			t.Errorf("%q: wrong error. expected=%q, got=%q", tt.input, tt.expected, errors[0].String())
+		}
+	}
+}
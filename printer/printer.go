@@ -0,0 +1,440 @@
+// Package printer formats a parsed program as canonical wordbuilder source.
+package printer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"wordbuilder/ast"
+	"wordbuilder/literal"
+	"wordbuilder/parser"
+	"wordbuilder/token"
+)
+
+// atom is the precedence of expressions that never need parentheses.
+const atom = parser.INDEX + 1
+
+type printer struct {
+	out      *bytes.Buffer
+	indent   int
+	comments ast.CommentMap
+	printed  map[token.Position]bool // comments already written
+	line     int                     // source line of the last thing printed
+	fresh    bool                    // nothing printed yet in the current file or block
+}
+
+// Fprint writes program to out as canonical source: one statement per line,
+// each terminated by ';', blocks indented with tabs and at most one blank line
+// between statements. A block holding a single statement on one line stays on
+// one line, as in `fn(x) { x * 2 }`, without the ';'.
+//
+// The comments attached to the program by the parser are written where they
+// were: on lines of their own before a statement, or at the end of its line.
+func Fprint(out io.Writer, program *ast.Program) error {
+	p := &printer{
+		out:      &bytes.Buffer{},
+		comments: program.Comments,
+		printed:  map[token.Position]bool{},
+		fresh:    true,
+	}
+	p.statements(program.Statements)
+	p.ownLine(p.group(program).Trailing)
+
+	_, err := out.Write(p.out.Bytes())
+	return err
+}
+
+// Source returns the source written by Fprint.
+func Source(program *ast.Program) string {
+	var out bytes.Buffer
+	Fprint(&out, program)
+	return out.String()
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) writeIndent() {
+	p.write(strings.Repeat("\t", p.indent))
+}
+
+// separate writes a blank line if the source had one or more blank lines
+// before line.
+func (p *printer) separate(line int) {
+	if !p.fresh && line > p.line+1 {
+		p.write("\n")
+	}
+	p.fresh = false
+}
+
+func (p *printer) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		group := p.group(stmt)
+		p.ownLine(group.Leading)
+		p.separate(stmt.Pos().Line)
+		p.writeIndent()
+		p.statement(stmt, true)
+		p.line = stmt.End().Line
+
+		for _, c := range group.Trailing {
+			p.write(" " + c.Literal)
+		}
+		p.write("\n")
+	}
+}
+
+// group returns the comments attached to node, which may be none.
+func (p *printer) group(node ast.Node) *ast.CommentGroup {
+	if group := p.comments[node]; group != nil {
+		return group
+	}
+	return &ast.CommentGroup{}
+}
+
+// ownLine writes comments on lines of their own.
+func (p *printer) ownLine(comments []token.Token) {
+	for _, c := range comments {
+		if p.printed[c.Pos] {
+			continue
+		}
+		p.separate(c.Pos.Line)
+		p.writeIndent()
+		p.write(c.Literal + "\n")
+		p.line = c.Pos.Line
+	}
+}
+
+// hasComments reports whether comments are attached to the statements of b
+// or to b itself.
+func (p *printer) hasComments(b *ast.BlockStatement) bool {
+	if p.comments[b] != nil {
+		return true
+	}
+	for _, stmt := range b.Statements {
+		if p.comments[stmt] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// statement writes stmt. An expression statement is terminated by ';' only
+// if terminate is set.
+func (p *printer) statement(stmt ast.Statement, terminate bool) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value)
+		p.write(";")
+
+	case *ast.ReturnStatement:
+		p.write("return")
+		if stmt.ReturnValue != nil {
+			p.write(" ")
+			p.expression(stmt.ReturnValue)
+		}
+		p.write(";")
+
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression)
+		if terminate {
+			p.write(";")
+		}
+
+	case *ast.WordStatement:
+		p.entry("word", literal.Quote(stmt.Name.Value), stmt.Attributes, stmt.Value)
+	case *ast.ReferenceStatement:
+		p.entry("ref", literal.Quote(stmt.Name.Value), stmt.Attributes, stmt.Value)
+	case *ast.ConceptStatement:
+		p.entry("cpt", literal.Quote(stmt.Name.Value), stmt.Attributes, stmt.Value)
+	case *ast.TranslationStatement:
+		p.entry("tr", stmt.Name.Value, stmt.Attributes, stmt.Value)
+
+	case *ast.QuoteStatement:
+		p.write("quote: " + literal.Quote(stmt.By))
+		p.attributes(stmt.Attributes)
+		if stmt.Rbrace.IsValid() {
+			p.write(" {" + literal.Quote(stmt.Text) + "}")
+		}
+		p.write(";")
+
+	case *ast.MeThoughtStatement:
+		if stmt.Content == "" {
+			p.write("me: {};")
+		} else {
+			p.write("me: {" + literal.Quote(stmt.Content) + "};")
+		}
+
+	case *ast.ImportStatement:
+		p.write("import " + literal.Quote(stmt.Path.Value) + ";")
+
+	case *ast.ForStatement:
+		p.write("for (")
+		if stmt.Key != nil {
+			p.write(stmt.Key.Value + ", ")
+		}
+		p.write(stmt.Value.Value + " in ")
+		p.expression(stmt.Iterable)
+		p.write(") ")
+		p.block(stmt.Body)
+		if terminate {
+			p.write(";")
+		}
+
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expression(stmt.Condition)
+		p.write(") ")
+		p.block(stmt.Body)
+		if terminate {
+			p.write(";")
+		}
+
+	case *ast.BranchStatement:
+		p.write(stmt.Token.Literal)
+		if terminate {
+			p.write(";")
+		}
+
+	case *ast.BlockStatement:
+		p.block(stmt)
+
+	default:
+		p.write(stmt.String())
+	}
+}
+
+// entry writes a vocabulary entry. A definition spanning several lines goes
+// on lines of its own between the braces, unless it already starts with a
+// line break.
+// attributes writes the attribute list of an entry or quote, if it has one.
+func (p *printer) attributes(attributes *ast.AttributeList) {
+	if attributes == nil {
+		return
+	}
+	p.write(" [")
+	for i, attr := range attributes.Attributes {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(attr.Key.Value + ": ")
+		p.expression(attr.Value)
+	}
+	p.write("]")
+}
+
+func (p *printer) entry(keyword, name string, attributes *ast.AttributeList, definition ast.Expression) {
+	p.write(keyword + ": " + name)
+	p.attributes(attributes)
+	if definition == nil {
+		p.write(";")
+		return
+	}
+
+	if str, ok := definition.(*ast.StringLiteral); ok && strings.Contains(str.Value, "\n") && !startsLine(str.Value) {
+		p.write(" {\n")
+		p.writeIndent()
+		p.write(literal.Quote(str.Value) + "\n")
+		p.writeIndent()
+		p.write("};")
+		return
+	}
+
+	p.write(" {")
+	p.expression(definition)
+	p.write("};")
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	if len(b.Statements) == 0 && !p.hasComments(b) {
+		p.write("{}")
+		return
+	}
+
+	if line, ok := p.oneLine(b); ok {
+		p.write("{ " + line + " }")
+		return
+	}
+
+	p.write("{")
+	p.line = b.Pos().Line
+	p.openingComment(b)
+	p.write("\n")
+
+	p.indent++
+	p.fresh = true
+	p.statements(b.Statements)
+	p.ownLine(p.group(b).Trailing)
+	p.indent--
+
+	p.writeIndent()
+	p.write("}")
+	p.line = b.Rbrace.Line
+}
+
+// openingComment writes the comment that follows the '{' of b on its line.
+func (p *printer) openingComment(b *ast.BlockStatement) {
+	comments := p.group(b).Trailing
+	if len(b.Statements) > 0 {
+		comments = p.group(b.Statements[0]).Leading
+	}
+
+	if len(comments) > 0 && comments[0].Pos.Line == b.Pos().Line {
+		p.write(" " + comments[0].Literal)
+		p.printed[comments[0].Pos] = true
+	}
+}
+
+// oneLine returns the body of b formatted on a single line, if b holds one
+// statement written on one line without comments.
+func (p *printer) oneLine(b *ast.BlockStatement) (string, bool) {
+	if len(b.Statements) != 1 || b.Pos().Line != b.Rbrace.Line || p.hasComments(b) {
+		return "", false
+	}
+
+	saved := p.out
+	p.out = &bytes.Buffer{}
+	p.statement(b.Statements[0], false)
+	line := p.out.String()
+	p.out = saved
+
+	return line, !strings.Contains(line, "\n")
+}
+
+func (p *printer) expression(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+
+	case *ast.IntegerLiteral:
+		p.write(e.Token.Literal)
+
+	case *ast.FloatLiteral:
+		p.write(e.Token.Literal)
+
+	case *ast.Boolean:
+		p.write(e.Token.Literal)
+
+	case *ast.StringLiteral:
+		p.write(literal.Quote(e.Value))
+
+	case *ast.InterpolatedString:
+		p.write(`"`)
+		for _, part := range e.Parts {
+			if str, ok := part.(*ast.StringLiteral); ok {
+				p.write(literal.Escape(str.Value))
+				continue
+			}
+			p.write("${")
+			p.expression(part)
+			p.write("}")
+		}
+		p.write(`"`)
+
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		p.operand(e.Right, parser.PREFIX)
+
+	case *ast.InfixExpression:
+		prec := parser.Precedence(e.Token.Type)
+		p.operand(e.Left, prec)
+		p.write(" " + e.Operator + " ")
+		p.operand(e.Right, prec+1)
+
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(e.Condition)
+		p.write(") ")
+		p.block(e.Consequence)
+		if e.Alternative != nil {
+			p.write(" else ")
+			p.block(e.Alternative)
+		}
+
+	case *ast.FunctionLiteral:
+		params := []string{}
+		for _, param := range e.Parameters {
+			params = append(params, param.Value)
+		}
+		p.write("fn(" + strings.Join(params, ", ") + ") ")
+		p.block(e.Body)
+
+	case *ast.CallExpression:
+		p.operand(e.Function, parser.CALL)
+		p.write("(")
+		p.list(e.Arguments)
+		p.write(")")
+
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(e.Elements)
+		p.write("]")
+
+	case *ast.IndexExpression:
+		p.operand(e.Left, parser.CALL)
+		p.write("[")
+		p.expression(e.Index)
+		p.write("]")
+
+	case *ast.FieldExpression:
+		p.operand(e.Left, parser.CALL)
+		p.write(".")
+		p.write(e.Field.Value)
+
+	case *ast.HashLiteral:
+		p.write("{")
+		for i, key := range e.Keys() {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(key)
+			p.write(": ")
+			p.expression(e.Pairs[key])
+		}
+		p.write("}")
+
+	case nil:
+
+	default:
+		p.write(e.String())
+	}
+}
+
+func (p *printer) list(exprs []ast.Expression) {
+	for i, e := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(e)
+	}
+}
+
+// operand writes e, in parentheses if it binds less tightly than prec.
+func (p *printer) operand(e ast.Expression, prec int) {
+	if precedence(e) < prec {
+		p.write("(")
+		p.expression(e)
+		p.write(")")
+		return
+	}
+	p.expression(e)
+}
+
+func precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(e.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression, *ast.FieldExpression:
+		return parser.INDEX
+	}
+	return atom
+}
+
+func startsLine(s string) bool {
+	return strings.HasPrefix(s, "\n") || strings.HasPrefix(s, "\r\n")
+}
@@ -0,0 +1,133 @@
+package printer
+
+import (
+	"testing"
+	"wordbuilder/lexer"
+	"wordbuilder/parser"
+)
+
+func format(t *testing.T, input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has %d errors for %q: %v", len(p.Errors()), input, p.Errors())
+	}
+	return Source(program)
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let   x=1+2*3", "let x = 1 + 2 * 3;\n"},
+		{"(1+2)*3;", "(1 + 2) * 3;\n"},
+		{"1-(2-3);(1-2)-3;", "1 - (2 - 3);\n1 - 2 - 3;\n"},
+		{"-(a+b);!(-a);", "-(a + b);\n!-a;\n"},
+		{"f(1)[0];[1,2][0];", "f(1)[0];\n[1, 2][0];\n"},
+		{`{"b": 1, "a": 2}`, "{\"b\": 1, \"a\": 2};\n"},
+		{"let f = fn(x){x*2};", "let f = fn(x) { x * 2 };\n"},
+		{"let f = fn(x){\nreturn x;}", "let f = fn(x) {\n\treturn x;\n};\n"},
+		{"if(a){1}else{\n2}", "if (a) { 1 } else {\n\t2;\n};\n"},
+		{"fn(){}", "fn() {};\n"},
+		{"a;\n\n\n\nb;\nc;", "a;\n\nb;\nc;\n"},
+		{"\n\nword:\"w\"", "word: \"w\";\n"},
+		{`word: "w" {"def"}; ref: "r"; cpt: "c" {"x"}; tr: Name {"y"};`,
+			"word: \"w\" {\"def\"};\nref: \"r\";\ncpt: \"c\" {\"x\"};\ntr: Name {\"y\"};\n"},
+		{"let x=1.50+-2.0*100000000000000000000;", "let x = 1.50 + -2.0 * 100000000000000000000;\n"},
+		{"a||b&&c<=d%2;(a||b)&&!c;", "a || b && c <= d % 2;\n(a || b) && !c;\n"},
+		{"for(k,v in h){if(v){continue}\nbreak}while(x){break};",
+			"for (k, v in h) {\n\tif (v) { continue };\n\tbreak;\n};\nwhile (x) { break };\n"},
+		{"puts(quotes()[0].by, (-h).key, h.a.b);",
+			"puts(quotes()[0].by, (-h).key, h.a.b);\n"},
+		{`word:"w"[tags:["a","b"],n:1+2]{"def"};tr: T [];`,
+			"word: \"w\" [tags: [\"a\", \"b\"], n: 1 + 2] {\"def\"};\ntr: T [];\n"},
+		{`quote:"Han"[lang:"ko"]{"text"};`, "quote: \"Han\" [lang: \"ko\"] {\"text\"};\n"},
+		{"word: \"w\" {\"one\ntwo\"};", "word: \"w\" {\n\"one\ntwo\"\n};\n"},
+		{"word: \"w\" {\"\none\n\"};", "word: \"w\" {\"\none\n\"};\n"},
+		{`puts("dijo \u{22}hola\" \\ \t");`, "puts(\"dijo \\\"hola\\\" \\\\ \t\");\n"},
+		{`word: "w" {"""1. "a"` + "\n" + `2. b"""};`, "word: \"w\" {\n\"\"\"1. \"a\"\n2. b\"\"\"\n};\n"},
+		{"word: \"w\" {\"one\r\ntwo\"};\r\nword: \"v\" {\"\"\"1. \"a\"\r\n2. b\"\"\"};\r\n",
+			"word: \"w\" {\n\"one\ntwo\"\n};\nword: \"v\" {\n\"\"\"1. \"a\"\n2. b\"\"\"\n};\n"},
+		{`word: "w" {"a\u{d}b"};`, "word: \"w\" {\"a\\u{d}b\"};\n"},
+		{`puts("word ${w.name}: ${len(w.definition)*2} \${x}",  "${ {"a":1} }");`,
+			"puts(\"word ${w.name}: ${len(w.definition) * 2} \\${x}\", \"${{\"a\": 1}}\");\n"},
+		{`quote: "By" {"text"}; me: {"thought"}; me: {}; import "lib.wb";`,
+			"quote: \"By\" {\"text\"};\nme: {\"thought\"};\nme: {};\nimport \"lib.wb\";\n"},
+	}
+
+	for _, tt := range tests {
+		if got := format(t, tt.input); got != tt.expected {
+			t.Errorf("wrong formatting of %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `# header
+
+
+word: "a"   {"x"};   # trailing
+let f = fn() { # opens
+  # inside
+      1; 2; # two
+  # before brace
+};
+a; b; # after b
+# end`
+
+	expected := `# header
+
+word: "a" {"x"}; # trailing
+let f = fn() { # opens
+	# inside
+	1;
+	2; # two
+	# before brace
+};
+a;
+b; # after b
+# end
+`
+
+	if got := format(t, input); got != expected {
+		t.Errorf("wrong formatting.\nexpected=%q\ngot=%q", expected, got)
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	input := `# vocabulary
+word: "arredrar" {"1. tr. Apartar.
+2. tr. Retraer."};
+ref:"William James";
+
+let apply=fn(f,x){ if (x>1) { return f(x) } else { x } };  # apply
+puts(apply(fn(x){x*2}, (1+2)*3));
+let h={"k": [1, 2, 3][0], true: !false};
+puts("${h["k"]} of ${len(words())}");
+quote: "Han" {"Some text"};
+me: {"A thought"};
+`
+
+	once := format(t, input)
+	if twice := format(t, once); twice != once {
+		t.Errorf("formatting is not stable.\nfirst=%q\nsecond=%q", once, twice)
+	}
+}
+
+func TestStringReparses(t *testing.T) {
+	input := `word: "w" {"def"}; ref: "r"; tr: Name {"y"}; quote: "By" {"text"}; me: {"thought"}; import "lib.wb"; puts("a ${x + 1} \${b}");`
+
+	program := parser.New(lexer.New(input)).ParseProgram()
+	for _, stmt := range program.Statements {
+		p := parser.New(lexer.New(stmt.String()))
+		reparsed := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Errorf("String() of %T does not parse: %q: %v", stmt, stmt.String(), p.Errors())
+			continue
+		}
+		if reparsed.String() != stmt.String() {
+			t.Errorf("String() changed after parsing. expected=%q, got=%q", stmt.String(), reparsed.String())
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package repl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+	"wordbuilder/evaluator"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+	"wordbuilder/serializer"
+)
+
+// metaCommand is a REPL command starting with ':'. run returns false to end
+// the session.
+type metaCommand struct {
+	name string
+	args string
+	help string
+	run  func(s *session, args []string) bool
+}
+
+var metaCommands []metaCommand
+
+func init() {
+	metaCommands = []metaCommand{
+		{"load", "file.wb", "evaluate a file into the session", (*session).loadCommand},
+		{"save", "file.wb", "write the entries, quotes and thoughts to a file as source", (*session).saveCommand},
+		{"words", "[definition|alpha|kind]", "list the vocabulary entries", (*session).wordsCommand},
+		{"env", "", "list the `let` variables", (*session).envCommand},
+		{"reset", "", "discard all variables and entries", (*session).resetCommand},
+		{"help", "", "show this help", (*session).helpCommand},
+		{"quit", "", "leave the REPL", (*session).quitCommand},
+	}
+}
+
+// command runs the meta-command in line. It returns false to end the session.
+func (s *session) command(line string) bool {
+	fields := strings.Fields(strings.TrimPrefix(line, ":"))
+	if len(fields) == 0 {
+		return s.helpCommand(nil)
+	}
+
+	for _, cmd := range metaCommands {
+		if cmd.name == fields[0] {
+			return cmd.run(s, fields[1:])
+		}
+	}
+
+	fmt.Fprintf(s.out, "unknown command :%s, type :help for a list\n", fields[0])
+	return true
+}
+
+func (s *session) loadCommand(args []string) bool {
+	if len(args) != 1 {
+		fmt.Fprintln(s.out, "usage: :load file.wb")
+		return true
+	}
+
+	path := args[0]
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return true
+	}
+
+	source := string(content)
+	p := parser.New(lexer.NewFile(path, source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParseErrors(s.out, p.Errors(), source)
+		return true
+	}
+
+	evaluated := evaluator.EvalFile(path, program, s.env)
+	if isError(evaluated) {
+		fmt.Fprintln(s.out, evaluated.Inspect())
+		return true
+	}
+
+	fmt.Fprintf(s.out, "loaded %s\n", path)
+	return true
+}
+
+func (s *session) saveCommand(args []string) bool {
+	if len(args) != 1 {
+		fmt.Fprintln(s.out, "usage: :save file.wb")
+		return true
+	}
+
+	source, err := serializer.String(s.env.Knowledge())
+	if err == nil {
+		err = ioutil.WriteFile(args[0], []byte(source), 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return true
+	}
+
+	fmt.Fprintf(s.out, "saved %d entries to %s\n", s.env.Knowledge().Len(), args[0])
+	return true
+}
+
+func (s *session) wordsCommand(args []string) bool {
+	order := object.DefinitionOrder
+	if len(args) > 0 {
+		var ok bool
+		if order, ok = object.LookupOrder(args[0]); !ok {
+			fmt.Fprintf(s.out, "unknown order %q, want definition, alpha or kind\n", args[0])
+			return true
+		}
+	}
+
+	entries := s.env.Knowledge().Entries(order)
+	for _, entry := range entries {
+		fmt.Fprintf(s.out, "%-4s %s\n", strings.ToLower(string(entry.Type())), entry.Name())
+	}
+	fmt.Fprintf(s.out, "%d entries\n", len(entries))
+	return true
+}
+
+func (s *session) envCommand(args []string) bool {
+	store := s.env.Store()
+	names := make([]string, 0, len(store))
+	for name := range store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(s.out, "%s = %s\n", name, summary(store[name].Inspect()))
+	}
+	return true
+}
+
+func (s *session) resetCommand(args []string) bool {
+	s.env = object.NewEnvironment()
+	fmt.Fprintln(s.out, "session reset")
+	return true
+}
+
+func (s *session) helpCommand(args []string) bool {
+	for _, cmd := range metaCommands {
+		usage := ":" + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(s.out, "  %-34s %s\n", usage, cmd.help)
+	}
+	fmt.Fprintln(s.out, "Statements can span several lines; an empty line evaluates them early.")
+	return true
+}
+
+func (s *session) quitCommand(args []string) bool {
+	return false
+}
+
+// summary shortens text to its first line and at most 60 characters.
+func summary(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i] + " ..."
+	}
+	if utf8.RuneCountInString(text) > 60 {
+		text = string([]rune(text)[:57]) + "..."
+	}
+	return text
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ErrorObj
+}
@@ -0,0 +1,86 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"wordbuilder/evaluator"
+	"wordbuilder/object"
+	"wordbuilder/token"
+)
+
+// complete returns the completions of the word before pos in line. At the
+// start of a line beginning with ':' it completes meta-commands; inside a
+// string literal it completes entry names, which may contain spaces;
+// elsewhere it completes keywords, builtins, `let` variables and the entry
+// names that can be written as identifiers.
+func (s *session) complete(line []rune, pos int) ([]string, int) {
+	if pos > 0 && line[0] == ':' && !strings.ContainsRune(string(line[:pos]), ' ') {
+		names := []string{}
+		for _, cmd := range metaCommands {
+			names = append(names, ":"+cmd.name)
+		}
+		return matching(names, string(line[:pos])), 0
+	}
+
+	if str, ok := openString(string(line[:pos])); ok {
+		opening := len(str) - len(strings.TrimLeft(str, `"`))
+		start := pos - len([]rune(str)) + opening
+		return matching(s.entryNames(), string(line[start:pos])), start
+	}
+
+	start := pos
+	for start > 0 && isWordRune(line[start-1]) {
+		start--
+	}
+	if start == pos {
+		return nil, pos
+	}
+
+	names := append(token.Keywords(), evaluator.BuiltinNames()...)
+	for name := range s.env.Store() {
+		names = append(names, name)
+	}
+	for _, name := range s.entryNames() {
+		if isIdentifier(name) {
+			names = append(names, name)
+		}
+	}
+
+	return matching(names, string(line[start:pos])), start
+}
+
+func (s *session) entryNames() []string {
+	names := []string{}
+	for _, entry := range s.env.Knowledge().Entries(object.DefinitionOrder) {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// matching returns the distinct names starting with prefix, sorted.
+func matching(names []string, prefix string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !isWordRune(r) || (i == 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return name != "" && token.LookupIdent(name) == token.Ident
+}
@@ -0,0 +1,51 @@
+package repl
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"wordbuilder/evaluator"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+)
+
+func TestComplete(t *testing.T) {
+	env := object.NewEnvironment()
+	program := parser.New(lexer.New(`
+word: "súcubo";
+word: "sucedáneo";
+ref: "Cueva de Alí Babá";
+let sumar = fn(x) { x };
+`)).ParseProgram()
+	evaluator.Eval(program, env)
+
+	s := &session{env: env, out: ioutil.Discard}
+
+	tests := []struct {
+		line          string
+		expected      []string
+		expectedStart int
+	}{
+		{"sú", []string{"súcubo"}, 0},
+		{"su", []string{"substr", "sucedáneo", "sumar"}, 0},
+		{"puts(wordc", []string{"wordcount"}, 5},
+		{"le", []string{"len", "let"}, 0},
+		{`defined("Cue`, []string{"Cueva de Alí Babá"}, 9},
+		{`defined("s`, []string{"sucedáneo", "súcubo"}, 9},
+		{`puts("\"a\"", "Cue`, []string{"Cueva de Alí Babá"}, 15},
+		{`doc("""Cue`, []string{"Cueva de Alí Babá"}, 7},
+		{":w", []string{":words"}, 0},
+		{"Cue", []string{}, 0},
+		{"1 + ", nil, 4},
+	}
+
+	for _, tt := range tests {
+		line := []rune(tt.line)
+		candidates, start := s.complete(line, len(line))
+		if !reflect.DeepEqual(candidates, tt.expected) || start != tt.expectedStart {
+			t.Errorf("complete(%q) wrong. expected=%q at %d, got=%q at %d",
+				tt.line, tt.expected, tt.expectedStart, candidates, start)
+		}
+	}
+}
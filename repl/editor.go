@@ -0,0 +1,286 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Control keys understood by the editor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// errInterrupt is returned by readLine when the user presses Ctrl-C.
+var errInterrupt = errors.New("interrupted")
+
+// completer returns the completions of the word that ends at pos in line,
+// and the index where that word starts.
+type completer func(line []rune, pos int) (candidates []string, start int)
+
+// editor is a small line editor for terminals in raw mode. It supports cursor
+// movement, Emacs-style control keys, history and tab completion.
+type editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	history  []string
+	complete completer
+}
+
+func newEditor(in io.Reader, out io.Writer, complete completer) *editor {
+	return &editor{in: bufio.NewReader(in), out: out, complete: complete}
+}
+
+// addHistory records line as the most recent history entry. It reports
+// false if line repeats the previous entry.
+func (e *editor) addHistory(line string) bool {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return false
+	}
+	e.history = append(e.history, line)
+	return true
+}
+
+// readLine shows prompt and returns the line typed by the user. It returns
+// io.EOF on Ctrl-D at an empty line and errInterrupt on Ctrl-C.
+func (e *editor) readLine(prompt string) (string, error) {
+	buf := []rune{}
+	pos := 0
+	hist := len(e.history)
+	pending := "" // the line being typed while browsing history
+
+	setLine := func(line string) {
+		buf = []rune(line)
+		pos = len(buf)
+	}
+
+	e.refresh(prompt, buf, pos)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if len(buf) > 0 {
+				io.WriteString(e.out, "\r\n")
+				return string(buf), nil
+			}
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			io.WriteString(e.out, "\r\n")
+			return string(buf), nil
+
+		case keyCtrlC:
+			io.WriteString(e.out, "^C\r\n")
+			return "", errInterrupt
+
+		case keyCtrlD:
+			if len(buf) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+
+		case keyTab:
+			buf, pos = e.completeWord(buf, pos)
+
+		case keyBackspace, keyCtrlH:
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+
+		case keyCtrlA:
+			pos = 0
+		case keyCtrlE:
+			pos = len(buf)
+		case keyCtrlB:
+			if pos > 0 {
+				pos--
+			}
+		case keyCtrlF:
+			if pos < len(buf) {
+				pos++
+			}
+		case keyCtrlU:
+			buf = buf[pos:]
+			pos = 0
+		case keyCtrlK:
+			buf = buf[:pos]
+
+		case keyCtrlP, keyCtrlN, keyEscape:
+			key := r
+			if r == keyEscape {
+				key = e.readEscape()
+			}
+			switch key {
+			case keyCtrlP:
+				if hist > 0 {
+					if hist == len(e.history) {
+						pending = string(buf)
+					}
+					hist--
+					setLine(e.history[hist])
+				}
+			case keyCtrlN:
+				if hist < len(e.history) {
+					hist++
+					if hist == len(e.history) {
+						setLine(pending)
+					} else {
+						setLine(e.history[hist])
+					}
+				}
+			case keyCtrlB:
+				if pos > 0 {
+					pos--
+				}
+			case keyCtrlF:
+				if pos < len(buf) {
+					pos++
+				}
+			case keyCtrlA:
+				pos = 0
+			case keyCtrlE:
+				pos = len(buf)
+			case keyCtrlD:
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+
+		default:
+			if unicode.IsPrint(r) {
+				buf = append(buf[:pos], append([]rune{r}, buf[pos:]...)...)
+				pos++
+			}
+		}
+
+		e.refresh(prompt, buf, pos)
+	}
+}
+
+// readEscape reads the rest of an escape sequence and maps the keys the
+// editor knows (arrows, Home, End and Delete) to their control key.
+func (e *editor) readEscape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return 0
+	}
+
+	switch r {
+	case 'A':
+		return keyCtrlP
+	case 'B':
+		return keyCtrlN
+	case 'C':
+		return keyCtrlF
+	case 'D':
+		return keyCtrlB
+	case 'H':
+		return keyCtrlA
+	case 'F':
+		return keyCtrlE
+	}
+
+	// Sequences such as "3~" (Delete), "1~" (Home) and "4~" (End).
+	code := r
+	for r >= '0' && r <= '9' {
+		if r, _, err = e.in.ReadRune(); err != nil {
+			return 0
+		}
+	}
+	if r != '~' {
+		return 0
+	}
+	switch code {
+	case '3':
+		return keyCtrlD
+	case '1', '7':
+		return keyCtrlA
+	case '4', '8':
+		return keyCtrlE
+	}
+	return 0
+}
+
+// completeWord completes the word before the cursor. A single candidate is
+// inserted; several candidates are completed up to their common prefix, or
+// listed if that adds nothing.
+func (e *editor) completeWord(buf []rune, pos int) ([]rune, int) {
+	if e.complete == nil {
+		return buf, pos
+	}
+
+	candidates, start := e.complete(buf, pos)
+	if len(candidates) == 0 {
+		return buf, pos
+	}
+
+	prefix := []rune(commonPrefix(candidates))
+	if len(prefix) > pos-start {
+		line := append(append(append([]rune{}, buf[:start]...), prefix...), buf[pos:]...)
+		return line, start + len(prefix)
+	}
+
+	if len(candidates) > 1 {
+		io.WriteString(e.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+	}
+	return buf, pos
+}
+
+// refresh redraws the prompt and the line, and places the cursor at pos.
+func (e *editor) refresh(prompt string, buf []rune, pos int) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(buf))
+	if back := displayWidth(buf[pos:]); back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+// displayWidth returns the number of terminal columns taken by runes,
+// ignoring combining marks.
+func displayWidth(runes []rune) int {
+	width := 0
+	for _, r := range runes {
+		if !unicode.Is(unicode.Mn, r) {
+			width++
+		}
+	}
+	return width
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		runes := []rune(word)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
@@ -0,0 +1,63 @@
+package repl
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestEditorReadLine(t *testing.T) {
+	complete := func(line []rune, pos int) ([]string, int) {
+		start := pos
+		for start > 0 && isWordRune(line[start-1]) {
+			start--
+		}
+		return matching([]string{"súcubo", "sucedáneo", "boato"}, string(line[start:pos])), start
+	}
+
+	tests := []struct {
+		input    string
+		history  []string
+		expected string
+	}{
+		{"let x = 1;\r", nil, "let x = 1;"},
+		{"abc\x7f\x7fxyz\r", nil, "axyz"},
+		{"bc\x01a\x05d\r", nil, "abcd"},
+		{"ac\x1b[Db\r", nil, "abc"},
+		{"abc\x1b[D\x1b[D\x1b[3~\r", nil, "ac"},
+		{"bo\t;\r", nil, "boato;"},
+		{"sú\t\r", nil, "súcubo"},
+		{"su\t\r", nil, "sucedáneo"},
+		{"\x1b[A\r", []string{"first", "second"}, "second"},
+		{"\x10\x10\r", []string{"first", "second"}, "first"},
+		{"new\x10\x0e\r", []string{"first"}, "new"},
+		{"abc\x02\x0b\x15x\r", nil, "x"},
+		{"abc\x02\x15x\r", nil, "xc"},
+	}
+
+	for _, tt := range tests {
+		e := newEditor(strings.NewReader(tt.input), ioutil.Discard, complete)
+		e.history = tt.history
+
+		line, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.input, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("%q: wrong line. expected=%q, got=%q", tt.input, tt.expected, line)
+		}
+	}
+}
+
+func TestEditorControlKeys(t *testing.T) {
+	e := newEditor(strings.NewReader("abc\x03\x04"), ioutil.Discard, nil)
+
+	if _, err := e.readLine(PROMPT); err != errInterrupt {
+		t.Errorf("expected errInterrupt after Ctrl-C, got %v", err)
+	}
+	if _, err := e.readLine(PROMPT); err != io.EOF {
+		t.Errorf("expected io.EOF after Ctrl-D on an empty line, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package repl
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// historyFile is the name of the file in the home directory where the REPL
+// keeps the lines typed in a terminal. WORDBUILDER_HISTORY overrides it.
+const historyFile = ".wordbuilder_history"
+
+// maxHistory is the number of lines kept in the history file.
+const maxHistory = 1000
+
+func historyPath() string {
+	if path := os.Getenv("WORDBUILDER_HISTORY"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFile)
+}
+
+// loadHistory reads the last maxHistory lines of the history file at path,
+// trimming the file if it has grown longer.
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+		ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	}
+	return lines
+}
+
+// appendHistory adds line to the end of the history file at path.
+func appendHistory(path, line string) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(line + "\n")
+	return err
+}
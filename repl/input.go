@@ -0,0 +1,112 @@
+package repl
+
+import (
+	"strings"
+	"wordbuilder/lexer"
+	"wordbuilder/token"
+)
+
+// continuedBy lists the tokens that cannot end a statement: after one of
+// them the REPL waits for the next line.
+var continuedBy = map[token.Type]bool{
+	token.Assign:   true,
+	token.Plus:     true,
+	token.Minus:    true,
+	token.Asterisk: true,
+	token.Slash:    true,
+	token.Percent:  true,
+	token.Bang:     true,
+	token.Lt:       true,
+	token.Gt:       true,
+	token.LtEq:     true,
+	token.GtEq:     true,
+	token.And:      true,
+	token.Or:       true,
+	token.Eq:       true,
+	token.NotEq:    true,
+	token.Comma:    true,
+	token.Colon:    true,
+	token.Dot:      true,
+	token.Let:      true,
+	token.Function: true,
+	token.If:       true,
+	token.Else:     true,
+	token.Import:   true,
+	token.For:      true,
+	token.While:    true,
+	token.In:       true,
+}
+
+// entryKeywords start statements that are only complete once their ';' has
+// been typed, so that a definition can follow the name on the next line.
+var entryKeywords = map[token.Type]bool{
+	token.Word:  true,
+	token.Ref:   true,
+	token.Cpt:   true,
+	token.Tr:    true,
+	token.Me:    true,
+	token.Quote: true,
+}
+
+// needsMore reports whether input is unfinished: it has an unterminated
+// string, an unclosed brace, bracket or parenthesis, or it ends in the middle
+// of a statement.
+func needsMore(input string) bool {
+	if depth, inString := delimiters(input); inString || depth > 0 {
+		return true
+	}
+
+	l := lexer.New(input)
+	var last token.Type
+	depth := 0
+	inEntry := false
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		atStatementStart := last == "" || last == token.Semicolon || last == token.RightBrace
+		switch {
+		case tok.Type == token.LeftBrace || tok.Type == token.LeftBracket || tok.Type == token.LeftParen:
+			depth++
+		case tok.Type == token.RightBrace || tok.Type == token.RightBracket || tok.Type == token.RightParen:
+			depth--
+		case tok.Type == token.Semicolon && depth == 0:
+			inEntry = false
+		case entryKeywords[tok.Type] && depth == 0 && atStatementStart:
+			inEntry = true
+		}
+		last = tok.Type
+	}
+
+	return inEntry || continuedBy[last]
+}
+
+// delimiters returns how many more braces, brackets and parentheses input
+// opens than it closes outside strings and comments, and whether it ends
+// inside a string.
+func delimiters(input string) (depth int, inString bool) {
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LeftBrace, token.LeftBracket, token.LeftParen, token.StringHead:
+			depth++
+		case token.RightBrace, token.RightBracket, token.RightParen, token.StringTail:
+			depth--
+		}
+	}
+
+	_, inString = openString(input)
+	return depth, inString
+}
+
+// openString returns the unterminated string at the end of input, from its
+// opening quotes, if there is one.
+func openString(input string) (string, bool) {
+	l := lexer.New(input)
+	var last token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		last = tok
+	}
+
+	if last.Type == token.Illegal && strings.HasPrefix(last.Literal, `"`) {
+		return last.Literal, true
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package repl
+
+import "testing"
+
+func TestNeedsMore(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`1 + 2`, false},
+		{`let x = 5;`, false},
+		{`let x =`, true},
+		{`1 +`, true},
+		{`max(1,`, true},
+		{`let f = fn(x) {`, true},
+		{`let f = fn(x) { x }`, false},
+		{`[1, 2`, true},
+		{`word: "arenga" {"`, true},
+		{"word: \"arenga\" {\"\nQuizá del occit.\n\"}", true},
+		{"word: \"arenga\" {\"\nQuizá del occit.\n\"};", false},
+		{`word: "boato"`, true},
+		{`word: "boato";`, false},
+		{`word:`, true},
+		{`me: {"una idea"};`, false},
+		{`"unterminated`, true},
+		{`"a { in a string"`, false},
+		{`"an escaped \" quote`, true},
+		{`"an escaped \" quote";`, false},
+		{`puts("a ${len(`, true},
+		{`puts("a ${x} b`, true},
+		{`puts("a ${x} b");`, false},
+		{`word: "cita" {"""dijo "hola"`, true},
+		{"word: \"cita\" {\"\"\"dijo \"hola\"\n\"\"\"};", false},
+		{`1 # a comment with {`, false},
+		{`if (true) { word: "x" }`, false},
+		{`for (w in words()) {`, true},
+		{`for (w in`, true},
+		{`while (true) { break; }`, false},
+		{`}`, false},
+	}
+
+	for _, tt := range tests {
+		if got := needsMore(tt.input); got != tt.expected {
+			t.Errorf("needsMore(%q) wrong. expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
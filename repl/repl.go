@@ -1,52 +1,152 @@
-package repl
-
-import (
-	"bufio"
-	"fmt"
-	"io"
-	"wordbuilder/evaluator"
-	"wordbuilder/lexer"
-	"wordbuilder/object"
-	"wordbuilder/parser"
-)
-
-// PROMPT ...
-const PROMPT = "$ "
-
-// Start ...
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
-
-	for {
-		fmt.Print(PROMPT)
-		scanned := scanner.Scan()
-
-		if !scanned {
-			return
-		}
-
-		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
-
-		program := p.ParseProgram()
-
-		if len(p.Errors()) != 0 {
-			printParseErrors(out, p.Errors())
-			continue
-		}
-
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
-		}
-	}
-}
-
-func printParseErrors(out io.Writer, errors []parser.Error) {
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg.String()+"\n")
-	}
-}
+package repl
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"wordbuilder/evaluator"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+)
+
+// PROMPT ...
+const PROMPT = "$ "
+
+// CONTINUATION is the prompt shown while a statement spans several lines.
+const CONTINUATION = "> "
+
+// session is the state of a running REPL.
+type session struct {
+	env *object.Environment
+	out io.Writer
+}
+
+// Start reads statements from in and evaluates them in env, which may already
+// hold a preloaded file. Input is collected over several lines until it forms
+// complete statements; an empty line evaluates whatever has been typed so far
+// unless it is inside a string. Lines starting with ':' are meta-commands.
+//
+// When in is a terminal, lines are read with a line editor that keeps a
+// history file and completes names with Tab.
+func Start(in io.Reader, out io.Writer, env *object.Environment) {
+	s := &session{env: env, out: out}
+	lines := newLineReader(in, out, s.complete)
+	input := ""
+
+	for {
+		prompt := PROMPT
+		if input != "" {
+			prompt = CONTINUATION
+		}
+
+		text, err := lines.readLine(prompt)
+		if err == errInterrupt {
+			input = ""
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		if input == "" && strings.HasPrefix(strings.TrimSpace(text), ":") {
+			if !s.command(strings.TrimSpace(text)) {
+				return
+			}
+			continue
+		}
+
+		if input == "" && strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		_, inString := delimiters(input)
+		forced := strings.TrimSpace(text) == "" && !inString
+		input += text + "\n"
+		if !forced && needsMore(input) {
+			continue
+		}
+
+		s.eval(input)
+		input = ""
+	}
+}
+
+func (s *session) eval(input string) {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		printParseErrors(s.out, p.Errors(), input)
+		return
+	}
+
+	evaluated := evaluator.Eval(program, s.env)
+	if evaluated != nil {
+		io.WriteString(s.out, evaluated.Inspect())
+		io.WriteString(s.out, "\n")
+	}
+}
+
+// lineReader reads the lines typed in the REPL.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+func newLineReader(in io.Reader, out io.Writer, complete completer) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		path := historyPath()
+		e := newEditor(in, out, complete)
+		e.history = loadHistory(path)
+		return &terminalReader{fd: f.Fd(), editor: e, historyPath: path}
+	}
+	return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+// scannerReader reads plain lines, for input that is not a terminal.
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *scannerReader) readLine(prompt string) (string, error) {
+	io.WriteString(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// terminalReader edits lines in raw mode and records them in the history
+// file. The terminal is only in raw mode while a line is being read.
+type terminalReader struct {
+	fd          uintptr
+	editor      *editor
+	historyPath string
+}
+
+func (r *terminalReader) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	line, err := r.editor.readLine(prompt)
+	restore()
+
+	if err == nil && strings.TrimSpace(line) != "" && r.editor.addHistory(line) {
+		appendHistory(r.historyPath, line)
+	}
+	return line, err
+}
+
+func printParseErrors(out io.Writer, errors []parser.Error, source string) {
+	for _, msg := range errors {
+		io.WriteString(out, msg.Render(source)+"\n")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+
+package repl
+
+import "errors"
+
+// The line editor is only available on Linux and macOS; elsewhere the REPL
+// reads plain lines.
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
@@ -0,0 +1,170 @@
+// Package serializer writes a knowledge base back out as wordbuilder source.
+package serializer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"wordbuilder/literal"
+	"wordbuilder/object"
+)
+
+// keywords maps entry types to the statement keyword that defines them.
+var keywords = map[object.Type]string{
+	object.WordObj:        "word",
+	object.ReferenceObj:   "ref",
+	object.ConceptObj:     "cpt",
+	object.TranslationObj: "tr",
+}
+
+// Write writes every entry of kb in definition order, then its quotes and
+// its thoughts, as statements that evaluate to the same knowledge base.
+// Definitions are written verbatim, so multi-line definitions keep their
+// line breaks, and the doc comment of an entry goes on the lines above it.
+func Write(out io.Writer, kb *object.KnowledgeBase) error {
+	w := bufio.NewWriter(out)
+
+	entries := kb.Entries(object.DefinitionOrder)
+	for _, entry := range entries {
+		if err := writeEntry(w, entry); err != nil {
+			return err
+		}
+	}
+
+	if len(entries) > 0 && len(kb.Quotes()) > 0 {
+		w.WriteString("\n")
+	}
+	for _, q := range kb.Quotes() {
+		if err := writeQuote(w, q); err != nil {
+			return err
+		}
+	}
+
+	if len(entries)+len(kb.Quotes()) > 0 && len(kb.Thoughts()) > 0 {
+		w.WriteString("\n")
+	}
+	for _, thought := range kb.Thoughts() {
+		fmt.Fprintf(w, "me: {%s};\n", literal.Quote(thought))
+	}
+
+	return w.Flush()
+}
+
+// String returns the source written by Write.
+func String(kb *object.KnowledgeBase) (string, error) {
+	var out bytes.Buffer
+	if err := Write(&out, kb); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func writeEntry(w *bufio.Writer, entry object.Entry) error {
+	keyword, ok := keywords[entry.Type()]
+	if !ok {
+		return fmt.Errorf("cannot write entries of type %s", entry.Type())
+	}
+
+	// Translations are named by an identifier, the other entries by a string.
+	name := entry.Name()
+	if entry.Type() != object.TranslationObj {
+		name = literal.Quote(name)
+	}
+
+	if entry.Doc() != "" {
+		for _, line := range strings.Split(entry.Doc(), "\n") {
+			w.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+	}
+
+	w.WriteString(keyword + ": " + name)
+	if err := writeAttributes(w, entry.Name(), entry.Attributes()); err != nil {
+		return err
+	}
+	if entry.Text() != "" {
+		w.WriteString(" {" + literal.Quote(entry.Text()) + "}")
+	}
+	w.WriteString(";\n")
+
+	return nil
+}
+
+func writeQuote(w *bufio.Writer, q object.Quote) error {
+	w.WriteString("quote: " + literal.Quote(q.By))
+	if err := writeAttributes(w, q.By, q.Attributes); err != nil {
+		return err
+	}
+	if q.Text != "" {
+		w.WriteString(" {" + literal.Quote(q.Text) + "}")
+	}
+	w.WriteString(";\n")
+
+	return nil
+}
+
+// writeAttributes writes attrs as an attribute list, if there are any. name
+// is the entry or quote they belong to, for the error.
+func writeAttributes(w *bufio.Writer, name string, attrs object.Attributes) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+	list := []string{}
+	for _, attr := range attrs {
+		value, err := source(attr.Value)
+		if err != nil {
+			return fmt.Errorf("cannot write attribute %q of %q: %s", attr.Key, name, err)
+		}
+		list = append(list, attr.Key+": "+value)
+	}
+	w.WriteString(" [" + strings.Join(list, ", ") + "]")
+
+	return nil
+}
+
+// source returns a literal that evaluates to obj.
+func source(obj object.Object) (string, error) {
+	switch obj := obj.(type) {
+	case *object.String:
+		return literal.Quote(obj.Value), nil
+	case *object.Integer, *object.BigInteger, *object.Boolean:
+		return obj.Inspect(), nil
+	case *object.Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return "", fmt.Errorf("%s has no source form", obj.Inspect())
+		}
+		return obj.Inspect(), nil
+
+	case *object.Array:
+		elements := []string{}
+		for _, el := range obj.Elements {
+			s, err := source(el)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, s)
+		}
+		return "[" + strings.Join(elements, ", ") + "]", nil
+
+	case *object.Hash:
+		pairs := []string{}
+		for _, pair := range obj.Pairs {
+			key, err := source(pair.Key)
+			if err != nil {
+				return "", err
+			}
+			value, err := source(pair.Value)
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, key+": "+value)
+		}
+		sort.Strings(pairs)
+		return "{" + strings.Join(pairs, ", ") + "}", nil
+	}
+
+	return "", fmt.Errorf("%s values have no source form", obj.Type())
+}
@@ -0,0 +1,143 @@
+package serializer_test
+
+import (
+	"reflect"
+	"testing"
+	"wordbuilder/evaluator"
+	"wordbuilder/lexer"
+	"wordbuilder/object"
+	"wordbuilder/parser"
+	"wordbuilder/serializer"
+)
+
+func load(t *testing.T, input string) *object.KnowledgeBase {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has %d errors: %v", len(p.Errors()), p.Errors())
+	}
+
+	env := object.NewEnvironment()
+	if result := evaluator.Eval(program, env); result != nil && result.Type() == object.ErrorObj {
+		t.Fatalf("evaluation failed: %s", result.Inspect())
+	}
+	return env.Knowledge()
+}
+
+func TestWrite(t *testing.T) {
+	input := `
+quote: "Byung-Chul Han" {"Some text ... "};
+word: "arenga" {"
+Quizá del occit. arenga.
+1. f. Discurso pronunciado para enardecer los ánimos de los oyentes.
+"};
+let unrelated = 1;
+me: {"I think what the author tried to say is ..."};
+# Ali Baba and the forty thieves.
+#
+#   told by Scheherazade
+ref: "Cueva de Alí Babá";
+if (true) {
+	cpt: "hybris" {"desmesura"};
+}
+tr: Weltanschauung {"cosmovisión"};
+word: "boato";
+`
+
+	expected := `word: "arenga" {"
+Quizá del occit. arenga.
+1. f. Discurso pronunciado para enardecer los ánimos de los oyentes.
+"};
+# Ali Baba and the forty thieves.
+#
+#   told by Scheherazade
+ref: "Cueva de Alí Babá";
+cpt: "hybris" {"desmesura"};
+tr: Weltanschauung {"cosmovisión"};
+word: "boato";
+
+quote: "Byung-Chul Han" {"Some text ... "};
+
+me: {"I think what the author tried to say is ..."};
+`
+
+	source, err := serializer.String(load(t, input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if source != expected {
+		t.Errorf("wrong source. expected=\n%s\ngot=\n%s", expected, source)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := `
+word: "arenga" {"
+Quizá del occit.
+"};
+# Demonio que adopta apariencia de mujer.
+word: "súcubo" [tags: ["2666", "latin"], lang: "es", page: 12, share: 0.25, id: 123456789012345678901234567890, seen: {"2024": true}];
+ref: "Musil" {"Robert Musil"};
+cpt: "hybris";
+tr: Zeitgeist {"espíritu de la época"};
+word: "arenga" {"redefinida"};
+quote: "Bolaño";
+quote: "Byung-Chul Han" [lang: "ko", year: 2010] {"Some text"};
+me: {};
+me: {"una idea"};
+`
+
+	kb := load(t, input)
+	source, err := serializer.String(kb)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	reloaded := load(t, source)
+
+	if !reflect.DeepEqual(kb.Entries(object.DefinitionOrder), reloaded.Entries(object.DefinitionOrder)) {
+		t.Errorf("entries differ after a round trip.\nsource:\n%s", source)
+	}
+	if !reflect.DeepEqual(kb.Quotes(), reloaded.Quotes()) {
+		t.Errorf("quotes differ after a round trip. expected=%v, got=%v", kb.Quotes(), reloaded.Quotes())
+	}
+	if !reflect.DeepEqual(kb.Thoughts(), reloaded.Thoughts()) {
+		t.Errorf("thoughts differ after a round trip. expected=%v, got=%v", kb.Thoughts(), reloaded.Thoughts())
+	}
+}
+
+func TestWriteQuotesAndBackslashes(t *testing.T) {
+	kb := object.NewKnowledgeBase()
+	kb.Define(&object.Word{Word: "cita", Definition: `dijo "hola" \ adiós`})
+	kb.Define(&object.Word{Word: "arenga", Definition: "1. f. Discurso.\n2. f. coloq. \"Sermón\" largo."})
+	kb.Define(&object.Word{Word: "plantilla", Definition: "Texto con ${huecos} de $5."})
+
+	source, err := serializer.String(kb)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `word: "cita" {"dijo \"hola\" \\ adiós"};
+word: "arenga" {"""1. f. Discurso.
+2. f. coloq. "Sermón" largo."""};
+word: "plantilla" {"Texto con \${huecos} de $5."};
+`
+	if source != expected {
+		t.Errorf("wrong source. expected=\n%s\ngot=\n%s", expected, source)
+	}
+
+	reloaded := load(t, source)
+	if !reflect.DeepEqual(kb.Entries(object.DefinitionOrder), reloaded.Entries(object.DefinitionOrder)) {
+		t.Errorf("entries differ after a round trip.\nsource:\n%s", source)
+	}
+}
+
+func TestWriteAttributeWithoutSource(t *testing.T) {
+	kb := load(t, `word: "f" [call: fn(x) { x }];`)
+
+	_, err := serializer.String(kb)
+	expected := `cannot write attribute "call" of "f": FUNCTION values have no source form`
+	if err == nil || err.Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%v", expected, err)
+	}
+}
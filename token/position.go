@@ -0,0 +1,76 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is a location in a source file. Lines and columns start at 1 and
+// columns are counted in characters, not bytes.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position points somewhere in a source file.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Before reports whether p comes before q in the same file.
+func (p Position) Before(q Position) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Column < q.Column)
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.File != "" {
+			return p.File
+		}
+		return "-"
+	}
+
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Excerpt returns the line of source pointed at by pos followed by a caret
+// under its column, e.g.
+//
+//	word: boato;
+//	      ^
+//
+// It returns an empty string when pos is not valid or is out of range.
+func Excerpt(source string, pos Position) string {
+	if !pos.IsValid() {
+		return ""
+	}
+
+	lines := strings.Split(source, "\n")
+	if pos.Line > len(lines) {
+		return ""
+	}
+
+	line := strings.TrimRight(lines[pos.Line-1], "\r")
+
+	var caret strings.Builder
+	column := 1
+	for _, ch := range line {
+		if column >= pos.Column {
+			break
+		}
+		// Keep tabs so the caret lines up with the text above it.
+		if ch == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+		column++
+	}
+	caret.WriteRune('^')
+
+	return line + "\n" + caret.String()
+}
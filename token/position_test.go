@@ -0,0 +1,43 @@
+package token
+
+import (
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	source := "word: \"a\";\r\n\tlet súcubo = y;\n"
+
+	tests := []struct {
+		pos      Position
+		expected string
+	}{
+		{Position{Line: 1, Column: 7}, "word: \"a\";\n      ^"},
+		{Position{Line: 2, Column: 15}, "\tlet súcubo = y;\n\t             ^"},
+		{Position{Line: 9, Column: 1}, ""},
+		{Position{}, ""},
+	}
+
+	for i, tt := range tests {
+		if got := Excerpt(source, tt.pos); got != tt.expected {
+			t.Errorf("tests[%d] - wrong excerpt. expected=%q, got=%q", i, tt.expected, got)
+		}
+	}
+}
+
+func TestBefore(t *testing.T) {
+	tests := []struct {
+		p, q     Position
+		expected bool
+	}{
+		{Position{Line: 1, Column: 5}, Position{Line: 2, Column: 1}, true},
+		{Position{Line: 2, Column: 1}, Position{Line: 2, Column: 3}, true},
+		{Position{Line: 2, Column: 3}, Position{Line: 2, Column: 3}, false},
+		{Position{Line: 3, Column: 1}, Position{Line: 2, Column: 9}, false},
+	}
+
+	for i, tt := range tests {
+		if got := tt.p.Before(tt.q); got != tt.expected {
+			t.Errorf("tests[%d] - %s.Before(%s) = %t, want %t", i, tt.p, tt.q, got, tt.expected)
+		}
+	}
+}
@@ -1,89 +1,135 @@
-package token
-
-type Type string
-
-type Token struct {
-	Type    Type
-	Literal string
-}
-
-const (
-	String = "STRING"
-	Colon  = ":"
-
-	Illegal = "ILLEGAL"
-	EOF     = "EOF"
-
-	// Ident Identifiers + literals
-	Ident = "IDENT" // add, foobar, x, y, ...
-	Int   = "INT"   // 1343456
-
-	// Assign ... Operators
-	Assign = "="
-	Plus   = "+"
-
-	// Comma ...
-	Comma     = ","
-	Semicolon = ";"
-
-	LeftParen  = "("
-	RightParen = ")"
-	LeftBrace  = "{"
-	RightBrace = "}"
-
-	// Keywords
-	Function = "FUNCTION"
-	Let      = "LET"
-
-	Word  = "WORD"
-	Ref   = "REF"
-	Cpt   = "CPT"
-	Tr    = "TR"
-	Me    = "ME"
-	Quote = "QUOTE"
-
-	True   = "TRUE"
-	False  = "FALSE"
-	If     = "IF"
-	Else   = "ELSE"
-	Return = "RETURN"
-
-	// Operators
-	// MINUS ...
-	Minus    = "-"
-	Bang     = "!"
-	Asterisk = "*"
-	Slash    = "/"
-
-	Lt = "<"
-	Gt = ">"
-
-	Eq    = "=="
-	NotEq = "!="
-
-	LeftBracket  = "["
-	RightBracket = "]"
-)
-
-var keywords = map[string]Type{
-	"fn":     Function,
-	"let":    Let,
-	"true":   True,
-	"false":  False,
-	"if":     If,
-	"else":   Else,
-	"return": Return,
-	"word":   Word,
-	"ref":    Ref,
-	"cpt":    Cpt,
-	"tr":     Tr,
-	"me":     Me,
-	"quote":  Quote,
-}
-
-func LookupIdent(ident string) Type {
-	if tok, ok := keywords[ident]; ok {
-		return tok
-	}
-	return Ident
-}
+package token
+
+import "sort"
+
+type Type string
+
+type Token struct {
+	Type    Type
+	Literal string
+	Pos     Position // position of the first character of the token
+	End     Position // position immediately after the token
+}
+
+const (
+	String = "STRING"
+	Colon  = ":"
+
+	// A string with "${...}" expressions is split around them: its text up to
+	// the first "${", between a "}" and the next "${", and from the last "}"
+	// to the closing '"'.
+	StringHead   = "STRING_HEAD"
+	StringMiddle = "STRING_MIDDLE"
+	StringTail   = "STRING_TAIL"
+
+	Illegal   = "ILLEGAL"
+	BadEscape = "BAD_ESCAPE" // an invalid escape sequence in a string
+	EOF       = "EOF"
+	Comment   = "COMMENT" // a '#' comment, up to the end of the line
+
+	// Ident Identifiers + literals
+	Ident = "IDENT" // add, foobar, x, y, ...
+	Int   = "INT"   // 1343456
+	Float = "FLOAT" // 3.14
+
+	// Assign ... Operators
+	Assign = "="
+	Plus   = "+"
+
+	// Comma ...
+	Comma     = ","
+	Semicolon = ";"
+
+	LeftParen  = "("
+	RightParen = ")"
+	LeftBrace  = "{"
+	RightBrace = "}"
+
+	// Keywords
+	Function = "FUNCTION"
+	Let      = "LET"
+
+	Word  = "WORD"
+	Ref   = "REF"
+	Cpt   = "CPT"
+	Tr    = "TR"
+	Me    = "ME"
+	Quote = "QUOTE"
+
+	Import = "IMPORT"
+
+	True   = "TRUE"
+	False  = "FALSE"
+	If     = "IF"
+	Else   = "ELSE"
+	Return = "RETURN"
+
+	For      = "FOR"
+	In       = "IN"
+	While    = "WHILE"
+	Break    = "BREAK"
+	Continue = "CONTINUE"
+
+	// Operators
+	// MINUS ...
+	Minus    = "-"
+	Bang     = "!"
+	Asterisk = "*"
+	Slash    = "/"
+	Percent  = "%"
+
+	Lt   = "<"
+	Gt   = ">"
+	LtEq = "<="
+	GtEq = ">="
+
+	Eq    = "=="
+	NotEq = "!="
+
+	And = "&&"
+	Or  = "||"
+
+	LeftBracket  = "["
+	RightBracket = "]"
+
+	Dot = "."
+)
+
+var keywords = map[string]Type{
+	"fn":       Function,
+	"let":      Let,
+	"true":     True,
+	"false":    False,
+	"if":       If,
+	"else":     Else,
+	"return":   Return,
+	"for":      For,
+	"in":       In,
+	"while":    While,
+	"break":    Break,
+	"continue": Continue,
+	"word":     Word,
+	"ref":      Ref,
+	"cpt":      Cpt,
+	"tr":       Tr,
+	"me":       Me,
+	"quote":    Quote,
+	"import":   Import,
+}
+
+// Keywords returns the reserved words of the language, sorted.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func LookupIdent(ident string) Type {
+	if tok, ok := keywords[ident]; ok {
+		return tok
+	}
+	return Ident
+}